@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,6 +13,9 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	UserType string `json:"user_type"`
 	Email    string `json:"email"`
+	// ClientID and Scope are only set on tokens issued to third-party apps.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -20,6 +24,10 @@ type JWTConfig struct {
 	RefreshTokenSecret string
 	AccessTokenExpiry  time.Duration
 	RefreshTokenExpiry time.Duration
+	// KeySet signs access tokens asymmetrically when set. HS256 tokens issued
+	// before the switch are then only accepted with AcceptLegacyHS256.
+	KeySet            *KeySet
+	AcceptLegacyHS256 bool
 }
 
 func (cfg *JWTConfig) GenerateAccessToken(userId, userType, email string) (string, error) {
@@ -38,6 +46,37 @@ func (cfg *JWTConfig) GenerateAccessToken(userId, userType, email string) (strin
 			Subject:   userId,
 		},
 	}
+	return cfg.signAccessToken(claims)
+}
+
+// GenerateClientAccessToken issues an access token to a third-party app. The
+// client id is the audience and the token carries only the granted scopes.
+func (cfg *JWTConfig) GenerateClientAccessToken(userId, userType, email, clientID string, scopes []string) (string, error) {
+	if userId == "" || userType == "" || email == "" || clientID == "" {
+		return "", errors.New("token requires user id, type, email and client id")
+	}
+	expirationTime := time.Now().Add(cfg.AccessTokenExpiry)
+	claims := Claims{
+		UserID:   userId,
+		UserType: userType,
+		Email:    email,
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   userId,
+			Audience:  jwt.ClaimStrings{clientID},
+		},
+	}
+	return cfg.signAccessToken(claims)
+}
+
+func (cfg *JWTConfig) signAccessToken(claims Claims) (string, error) {
+	if cfg.KeySet != nil {
+		return cfg.KeySet.Active.Sign(claims)
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(cfg.AccessTokenSecret))
 }
@@ -65,9 +104,7 @@ func (cfg *JWTConfig) GenerateRefreshToken(userId, userType, email string) (stri
 func (cfg *JWTConfig) ValidateAccessToken(tokenString string) (claims *Claims, valid bool, err error) {
 	claims = &Claims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		return []byte(cfg.AccessTokenSecret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, cfg.accessTokenKey)
 	if err != nil {
 		return nil, false, err
 	}
@@ -77,6 +114,32 @@ func (cfg *JWTConfig) ValidateAccessToken(tokenString string) (claims *Claims, v
 	return claims, true, nil
 }
 
+// accessTokenKey picks the verification key by the kid header. Tokens without
+// kid are HS256 tokens signed with the shared secret, which a key set only
+// accepts while AcceptLegacyHS256 is set.
+func (cfg *JWTConfig) accessTokenKey(token *jwt.Token) (any, error) {
+	if kid, ok := token.Header["kid"].(string); ok {
+		if cfg.KeySet == nil {
+			return nil, errors.New("unknown key id")
+		}
+		key, ok := cfg.KeySet.Keys[kid]
+		if !ok {
+			return nil, errors.New("unknown key id")
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.Public, nil
+	}
+	if cfg.KeySet != nil && !cfg.AcceptLegacyHS256 {
+		return nil, errors.New("token requires a key id")
+	}
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || cfg.AccessTokenSecret == "" {
+		return nil, errors.New("unexpected signing method")
+	}
+	return []byte(cfg.AccessTokenSecret), nil
+}
+
 func (cfg *JWTConfig) ValidateRefreshToken(tokenString string) (claims *Claims, valid bool, err error) {
 	claims = &Claims{}
 
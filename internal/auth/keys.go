@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is an asymmetric key used for access tokens. Retired keys have no
+// private part and are only kept so tokens signed with them stay valid.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeySet holds all keys access tokens can be verified with and the one new
+// tokens are signed with.
+type KeySet struct {
+	Active *Key
+	Keys   map[string]*Key
+}
+
+func NewKey(id string, private crypto.Signer) (*Key, error) {
+	key, err := NewVerificationKey(id, private.Public())
+	if err != nil {
+		return nil, err
+	}
+	key.Private = private
+	return key, nil
+}
+
+func NewVerificationKey(id string, public crypto.PublicKey) (*Key, error) {
+	if id == "" {
+		return nil, errors.New("key requires an id")
+	}
+	switch public.(type) {
+	case *rsa.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Public: public}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Public: public}, nil
+	}
+	return nil, fmt.Errorf("key %s: unsupported key type %T", id, public)
+}
+
+func NewKeySet(activeID string, keys ...*Key) (*KeySet, error) {
+	set := &KeySet{Keys: make(map[string]*Key, len(keys))}
+	for _, k := range keys {
+		if _, ok := set.Keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate key id %s", k.ID)
+		}
+		set.Keys[k.ID] = k
+	}
+	active, ok := set.Keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active key %s not found", activeID)
+	}
+	if active.Private == nil {
+		return nil, fmt.Errorf("active key %s has no private key", activeID)
+	}
+	set.Active = active
+	return set, nil
+}
+
+// LoadKeySet reads every PEM file of dir as a key, the file name without
+// extension being the key id. Private keys (PKCS#8 RSA or Ed25519, PKCS#1 RSA)
+// can sign, public keys (PKIX) only verify. A .pub.pem file next to the private
+// key of the same id is skipped.
+func LoadKeySet(dir, activeID string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	var keys []*Key
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".pub.pem") {
+			if _, err := os.Stat(strings.TrimSuffix(path, ".pub.pem") + ".pem"); err == nil {
+				continue
+			}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".pem"), ".pub")
+		key, err := ParseKeyPEM(id, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return NewKeySet(activeID, keys...)
+}
+
+func ParseKeyPEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM data", id)
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key %s: unsupported key type %T", id, parsed)
+		}
+		return NewKey(id, signer)
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		return NewKey(id, parsed)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		return NewVerificationKey(id, parsed)
+	}
+	return nil, fmt.Errorf("key %s: unsupported PEM block %s", id, block.Type)
+}
+
+func (k *Key) Sign(claims jwt.Claims) (string, error) {
+	if k.Private == nil {
+		return "", fmt.Errorf("key %s can't sign", k.ID)
+	}
+	token := jwt.NewWithClaims(k.Method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.Private)
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public part of every key as a JSON Web Key Set (RFC 7517).
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	if s == nil {
+		return jwks
+	}
+	for _, k := range s.Keys {
+		jwk := JWK{Use: "sig", Alg: k.Method.Alg(), Kid: k.ID}
+		switch pub := k.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeys(t *testing.T) (*Key, *Key) {
+	t.Helper()
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaKey, err := NewKey("rsa-1", rsaPrivate)
+	require.NoError(t, err)
+
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edKey, err := NewKey("ed-1", edPrivate)
+	require.NoError(t, err)
+	return rsaKey, edKey
+}
+
+func TestJWTConfig_KeySet(t *testing.T) {
+	rsaKey, edKey := newTestKeys(t)
+
+	t.Run("should sign with the active key and set kid", func(t *testing.T) {
+		for _, active := range []*Key{rsaKey, edKey} {
+			keySet, err := NewKeySet(active.ID, rsaKey, edKey)
+			require.NoError(t, err)
+			cfg := &JWTConfig{AccessTokenExpiry: 15 * time.Minute, KeySet: keySet}
+
+			token, err := cfg.GenerateAccessToken("user-123", "coach", "coach@fitterby.com")
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, active.ID, parsed.Header["kid"])
+			assert.Equal(t, active.Method.Alg(), parsed.Method.Alg())
+
+			claims, valid, err := cfg.ValidateAccessToken(token)
+			require.NoError(t, err)
+			assert.True(t, valid)
+			assert.Equal(t, "user-123", claims.UserID)
+		}
+	})
+
+	t.Run("should keep validating tokens of a retired key", func(t *testing.T) {
+		oldSet, err := NewKeySet(rsaKey.ID, rsaKey)
+		require.NoError(t, err)
+		oldCfg := &JWTConfig{AccessTokenExpiry: 15 * time.Minute, KeySet: oldSet}
+		token, err := oldCfg.GenerateAccessToken("user-123", "user", "user@fitterby.com")
+		require.NoError(t, err)
+
+		retired, err := NewVerificationKey(rsaKey.ID, rsaKey.Public)
+		require.NoError(t, err)
+		newSet, err := NewKeySet(edKey.ID, edKey, retired)
+		require.NoError(t, err)
+		newCfg := &JWTConfig{AccessTokenExpiry: 15 * time.Minute, KeySet: newSet}
+
+		_, valid, err := newCfg.ValidateAccessToken(token)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("should reject unknown kid", func(t *testing.T) {
+		signingSet, err := NewKeySet(rsaKey.ID, rsaKey)
+		require.NoError(t, err)
+		token, err := (&JWTConfig{AccessTokenExpiry: time.Minute, KeySet: signingSet}).GenerateAccessToken("user-123", "user", "user@fitterby.com")
+		require.NoError(t, err)
+
+		otherSet, err := NewKeySet(edKey.ID, edKey)
+		require.NoError(t, err)
+		claims, valid, err := (&JWTConfig{KeySet: otherSet}).ValidateAccessToken(token)
+
+		assert.Error(t, err)
+		assert.False(t, valid)
+		assert.Nil(t, claims)
+	})
+
+	t.Run("should reject HMAC token claiming an asymmetric kid", func(t *testing.T) {
+		keySet, err := NewKeySet(rsaKey.ID, rsaKey)
+		require.NoError(t, err)
+		cfg := &JWTConfig{AccessTokenSecret: "shared-secret", KeySet: keySet}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{UserID: "user-123"})
+		token.Header["kid"] = rsaKey.ID
+		tokenString, err := token.SignedString(x509.MarshalPKCS1PublicKey(rsaKey.Public.(*rsa.PublicKey)))
+		require.NoError(t, err)
+
+		_, valid, err := cfg.ValidateAccessToken(tokenString)
+
+		assert.Error(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("should reject HS256 tokens unless legacy tokens are accepted", func(t *testing.T) {
+		keySet, err := NewKeySet(edKey.ID, edKey)
+		require.NoError(t, err)
+		cfg := &JWTConfig{AccessTokenSecret: testConfig.AccessTokenSecret, AccessTokenExpiry: time.Minute, KeySet: keySet}
+
+		token, err := testConfig.GenerateAccessToken("user-123", "user", "user@fitterby.com")
+		require.NoError(t, err)
+
+		_, valid, err := cfg.ValidateAccessToken(token)
+		assert.Error(t, err)
+		assert.False(t, valid)
+
+		cfg.AcceptLegacyHS256 = true
+		_, valid, err = cfg.ValidateAccessToken(token)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		cfg.AccessTokenSecret = ""
+		_, valid, err = cfg.ValidateAccessToken(token)
+		assert.Error(t, err)
+		assert.False(t, valid)
+	})
+}
+
+func TestNewKeySet(t *testing.T) {
+	rsaKey, edKey := newTestKeys(t)
+
+	t.Run("should fail without the active key", func(t *testing.T) {
+		_, err := NewKeySet("missing", rsaKey, edKey)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail when the active key can't sign", func(t *testing.T) {
+		retired, err := NewVerificationKey(rsaKey.ID, rsaKey.Public)
+		require.NoError(t, err)
+
+		_, err = NewKeySet(retired.ID, retired)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail with duplicate ids", func(t *testing.T) {
+		_, err := NewKeySet(rsaKey.ID, rsaKey, rsaKey)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadKeySet(t *testing.T) {
+	t.Run("should load private and public keys", func(t *testing.T) {
+		dir := t.TempDir()
+		_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "2025-10.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+		rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		der, err = x509.MarshalPKIXPublicKey(&rsaPrivate.PublicKey)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "2025-01.pub.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+		keySet, err := LoadKeySet(dir, "2025-10")
+
+		require.NoError(t, err)
+		assert.Equal(t, "2025-10", keySet.Active.ID)
+		assert.Len(t, keySet.Keys, 2)
+		assert.Nil(t, keySet.Keys["2025-01"].Private)
+	})
+
+	t.Run("should skip the public key of a private key", func(t *testing.T) {
+		dir := t.TempDir()
+		_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(edPrivate)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "k1.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+		der, err = x509.MarshalPKIXPublicKey(edPrivate.Public())
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "k1.pub.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+		keySet, err := LoadKeySet(dir, "k1")
+
+		require.NoError(t, err)
+		assert.Len(t, keySet.Keys, 1)
+		assert.NotNil(t, keySet.Keys["k1"].Private)
+	})
+}
+
+func TestKeySet_JWKS(t *testing.T) {
+	t.Run("should publish public keys", func(t *testing.T) {
+		rsaKey, edKey := newTestKeys(t)
+		keySet, err := NewKeySet(rsaKey.ID, rsaKey, edKey)
+		require.NoError(t, err)
+
+		jwks := keySet.JWKS()
+
+		require.Len(t, jwks.Keys, 2)
+		assert.Equal(t, JWK{Kty: "OKP", Use: "sig", Alg: "EdDSA", Kid: "ed-1", Crv: "Ed25519", X: jwks.Keys[0].X}, jwks.Keys[0])
+		assert.NotEmpty(t, jwks.Keys[0].X)
+		assert.Equal(t, "RSA", jwks.Keys[1].Kty)
+		assert.Equal(t, "RS256", jwks.Keys[1].Alg)
+		assert.Equal(t, "AQAB", jwks.Keys[1].E)
+		assert.NotEmpty(t, jwks.Keys[1].N)
+	})
+
+	t.Run("should return an empty set without keys", func(t *testing.T) {
+		var keySet *KeySet
+
+		assert.Empty(t, keySet.JWKS().Keys)
+	})
+}
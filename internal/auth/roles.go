@@ -0,0 +1,16 @@
+package auth
+
+const (
+	RoleUser      = "user"
+	RoleCoach     = "coach"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+func ValidRole(role string) bool {
+	switch role {
+	case RoleUser, RoleCoach, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package auth
+
+// Scopes limit what an API key or a third-party app may do.
+const (
+	ScopeProfileRead   = "profile:read"
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeProgramsRead  = "programs:read"
+	ScopeProgramsWrite = "programs:write"
+	ScopeWorkoutsRead  = "workouts:read"
+	ScopeWorkoutsWrite = "workouts:write"
+)
+
+var Scopes = []string{
+	ScopeProfileRead,
+	ScopePostsRead,
+	ScopePostsWrite,
+	ScopeProgramsRead,
+	ScopeProgramsWrite,
+	ScopeWorkoutsRead,
+	ScopeWorkoutsWrite,
+}
+
+// ScopeDescriptions are shown to users on the consent screen.
+var ScopeDescriptions = map[string]string{
+	ScopeProfileRead:   "See your profile and email address",
+	ScopePostsRead:     "See posts of people you follow",
+	ScopePostsWrite:    "Create posts, comments and likes",
+	ScopeProgramsRead:  "See the programs you're subscribed to",
+	ScopeProgramsWrite: "Create programs and exercises and subscribe to programs",
+	ScopeWorkoutsRead:  "See your workouts",
+	ScopeWorkoutsWrite: "Log workouts",
+}
+
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
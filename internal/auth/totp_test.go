@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// RFC 6238 appendix B secret for SHA1
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	t.Run("should match RFC 6238 test vectors", func(t *testing.T) {
+		// the RFC lists 8 digit codes, we use the last 6
+		vectors := map[int64]string{
+			59:          "287082",
+			1111111109:  "081804",
+			1111111111:  "050471",
+			1234567890:  "005924",
+			2000000000:  "279037",
+			20000000000: "353130",
+		}
+		for unix, expected := range vectors {
+			code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(unix, 0)))
+			require.NoError(t, err)
+			assert.Equal(t, expected, code, "time %d", unix)
+		}
+	})
+
+	t.Run("should fail with malformed secret", func(t *testing.T) {
+		_, err := TOTPCode("not base32!", 1)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	t.Run("should accept current code", func(t *testing.T) {
+		step, valid := ValidateTOTP(rfcSecret, "050471", now)
+
+		assert.True(t, valid)
+		assert.Equal(t, TOTPStep(now), step)
+	})
+
+	t.Run("should accept code from previous period", func(t *testing.T) {
+		code, err := TOTPCode(rfcSecret, TOTPStep(now)-1)
+		require.NoError(t, err)
+
+		step, valid := ValidateTOTP(rfcSecret, code, now)
+
+		assert.True(t, valid)
+		assert.Equal(t, TOTPStep(now)-1, step)
+	})
+
+	t.Run("should reject code outside the skew window", func(t *testing.T) {
+		code, err := TOTPCode(rfcSecret, TOTPStep(now)-3)
+		require.NoError(t, err)
+
+		_, valid := ValidateTOTP(rfcSecret, code, now)
+
+		assert.False(t, valid)
+	})
+
+	t.Run("should reject wrong length", func(t *testing.T) {
+		_, valid := ValidateTOTP(rfcSecret, "12345", now)
+
+		assert.False(t, valid)
+	})
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	t.Run("should generate usable unique secrets", func(t *testing.T) {
+		first, err := GenerateTOTPSecret()
+		require.NoError(t, err)
+		second, err := GenerateTOTPSecret()
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first, second)
+		_, err = TOTPCode(first, 1)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTOTPURI(t *testing.T) {
+	t.Run("should build otpauth uri", func(t *testing.T) {
+		uri := TOTPURI("fitterBy", "coach@fitterby.com", "JBSWY3DPEHPK3PXP")
+
+		assert.True(t, strings.HasPrefix(uri, "otpauth://totp/fitterBy:coach@fitterby.com?"))
+		assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+		assert.Contains(t, uri, "issuer=fitterBy")
+	})
+}
@@ -87,8 +87,18 @@ const getExercises = `-- name: GetExercises :many
 SELECT exercises.id, exercises.name, exercises.user_id, exercises.description, exercises.media_urls, exercises.created_at, users.name as author_name
 FROM exercises
 LEFT JOIN users ON exercises.user_id = users.id
+WHERE $1::timestamp IS NULL
+OR (exercises.created_at, exercises.id) < ($1::timestamp, $2::uuid)
+ORDER BY exercises.created_at DESC, exercises.id DESC
+LIMIT $3
 `
 
+type GetExercisesParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
 type GetExercisesRow struct {
 	ID          uuid.UUID
 	Name        string
@@ -99,8 +109,8 @@ type GetExercisesRow struct {
 	AuthorName  sql.NullString
 }
 
-func (q *Queries) GetExercises(ctx context.Context) ([]GetExercisesRow, error) {
-	rows, err := q.db.QueryContext(ctx, getExercises)
+func (q *Queries) GetExercises(ctx context.Context, arg GetExercisesParams) ([]GetExercisesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getExercises, arg.CursorCreatedAt, arg.CursorID, arg.PageSize)
 	if err != nil {
 		return nil, err
 	}
@@ -11,6 +11,51 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiKey struct {
+	ID         uuid.UUID
+	UserID     uuid.UUID
+	Name       string
+	KeyPrefix  string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	ExpiresAt  sql.NullTime
+}
+
+type AuthEvent struct {
+	ID        uuid.UUID
+	UserID    uuid.NullUUID
+	EventType string
+	Details   string
+	CreatedAt time.Time
+	IpAddress string
+}
+
+type CommentLike struct {
+	UserID    uuid.UUID
+	CommentID uuid.UUID
+	CreatedAt time.Time
+}
+
+type CommentRevision struct {
+	ID        uuid.UUID
+	CommentID uuid.UUID
+	EditorID  uuid.NullUUID
+	Content   string
+	CreatedAt time.Time
+}
+
+type EmailVerificationToken struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	Email       string
+	TokenHash   string
+	ExpiresAt   time.Time
+	EmailChange bool
+}
+
 type Exercise struct {
 	ID          uuid.UUID
 	Name        string
@@ -20,6 +65,108 @@ type Exercise struct {
 	CreatedAt   time.Time
 }
 
+type LoginAttempt struct {
+	AttemptKey    string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type MediaFile struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	StorageKey   string
+	ThumbnailKey string
+	ContentType  string
+	SizeBytes    int64
+	Width        int32
+	Height       int32
+	CreatedAt    time.Time
+	AttachedAt   sql.NullTime
+}
+
+type MfaChallenge struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	Attempts  int32
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Type      string
+	PostID    uuid.NullUUID
+	CommentID uuid.NullUUID
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+type OauthAuthorizationCode struct {
+	ID                  uuid.UUID
+	CodeHash            string
+	GrantID             uuid.UUID
+	RedirectUri         string
+	Scopes              []string
+	CodeChallenge       string
+	ExpiresAt           time.Time
+	CreatedAt           time.Time
+	RedirectUriExplicit bool
+}
+
+type OauthClient struct {
+	ID               uuid.UUID
+	ClientID         string
+	ClientSecretHash string
+	OwnerID          uuid.UUID
+	Name             string
+	RedirectUris     []string
+	CreatedAt        time.Time
+}
+
+type OauthGrant struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ClientID  string
+	Scopes    []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type OauthRefreshToken struct {
+	ID        uuid.UUID
+	TokenHash string
+	GrantID   uuid.UUID
+	Scopes    []string
+	ExpiresAt time.Time
+	RotatedAt sql.NullTime
+	CreatedAt time.Time
+}
+
+type OauthState struct {
+	ID            uuid.UUID
+	StateHash     string
+	Provider      string
+	CodeVerifier  string
+	Nonce         string
+	LinkUserID    uuid.NullUUID
+	CookieSession bool
+	ExpiresAt     time.Time
+	CreatedAt     time.Time
+}
+
+type PasswordResetToken struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
 type Post struct {
 	ID           uuid.UUID
 	UserID       uuid.UUID
@@ -30,14 +177,39 @@ type Post struct {
 	CommentCount int32
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	EditedAt     sql.NullTime
+	DeletedAt    sql.NullTime
+	WorkoutID    uuid.NullUUID
+	ProgramID    uuid.NullUUID
+	RepostOf     uuid.NullUUID
+}
+
+type PostRevision struct {
+	ID         uuid.UUID
+	PostID     uuid.UUID
+	EditorID   uuid.NullUUID
+	Content    string
+	MediaUrls  []string
+	Visibility string
+	CreatedAt  time.Time
+}
+
+type PostTag struct {
+	PostID uuid.UUID
+	TagID  uuid.UUID
 }
 
 type PostsComment struct {
-	ID        uuid.UUID
-	UserID    uuid.UUID
-	PostID    uuid.UUID
-	Content   string
-	CreatedAt time.Time
+	ID              uuid.UUID
+	UserID          uuid.NullUUID
+	PostID          uuid.UUID
+	Content         string
+	CreatedAt       time.Time
+	EditedAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	ParentCommentID uuid.NullUUID
+	RootCommentID   uuid.NullUUID
+	Depth           int32
 }
 
 type PostsLike struct {
@@ -77,29 +249,95 @@ type ProgramLift struct {
 	CreatedAt    sql.NullTime
 }
 
+type ProgramTag struct {
+	ProgramID uuid.UUID
+	TagID     uuid.UUID
+}
+
 type RefreshToken struct {
+	ID         uuid.UUID
+	CreatedAt  sql.NullTime
+	UserID     uuid.UUID
+	TokenHash  string
+	ExpiresAt  time.Time
+	FamilyID   uuid.UUID
+	RotatedAt  sql.NullTime
+	LastUsedAt time.Time
+	UserAgent  string
+	IpAddress  string
+}
+
+type Tag struct {
+	ID        uuid.UUID
+	Name      string
+	CreatedAt time.Time
+}
+
+type TotpRecoveryCode struct {
 	ID        uuid.UUID
-	CreatedAt sql.NullTime
 	UserID    uuid.UUID
-	TokenHash string
-	ExpiresAt time.Time
+	CodeHash  string
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
 }
 
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Name           string
-	Email          string
-	Bio            string
-	HashedPassword string
-	Premium        bool
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Email               string
+	Bio                 string
+	HashedPassword      string
+	Premium             bool
+	Role                string
+	EmailVerifiedAt     sql.NullTime
+	DeletionScheduledAt sql.NullTime
+}
+
+type UserBlock struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
 }
 
 type UserFollow struct {
 	FollowerID uuid.UUID
 	FollowedID uuid.UUID
-	CreatedAt  sql.NullTime
+	CreatedAt  time.Time
+	Status     string
+}
+
+type UserIdentity struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+type UserMute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type UserSetting struct {
+	UserID            uuid.UUID
+	AvatarUrl         string
+	WeightUnit        string
+	Timezone          string
+	ProfileVisibility string
+	UpdatedAt         time.Time
+}
+
+type UserTotp struct {
+	UserID       uuid.UUID
+	Secret       string
+	ConfirmedAt  sql.NullTime
+	LastUsedStep int64
+	CreatedAt    time.Time
 }
 
 type UsersLift struct {
@@ -127,5 +365,5 @@ type Workout struct {
 	ID           uuid.UUID
 	UserID       uuid.UUID
 	ProgramDayID uuid.UUID
-	CreatedAt    sql.NullTime
+	CreatedAt    time.Time
 }
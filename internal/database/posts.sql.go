@@ -35,35 +35,85 @@ func (q *Queries) CheckUserLikedPost(ctx context.Context, arg CheckUserLikedPost
 	return user_liked, err
 }
 
-const commentOnPost = `-- name: CommentOnPost :exec
-INSERT INTO posts_comments(user_id, post_id, content)
+const commentOnPost = `-- name: CommentOnPost :one
+INSERT INTO posts_comments(user_id, post_id, content, parent_comment_id, root_comment_id, depth)
 VALUES (
 		$1,
 		$2,
-		$3
+		$3,
+		$4,
+		$5,
+		$6
 		)
+RETURNING id, user_id, post_id, content, created_at, edited_at, deleted_at, parent_comment_id, root_comment_id, depth
 `
 
 type CommentOnPostParams struct {
-	UserID  uuid.UUID
-	PostID  uuid.UUID
-	Content string
+	UserID          uuid.NullUUID
+	PostID          uuid.UUID
+	Content         string
+	ParentCommentID uuid.NullUUID
+	RootCommentID   uuid.NullUUID
+	Depth           int32
+}
+
+func (q *Queries) CommentOnPost(ctx context.Context, arg CommentOnPostParams) (PostsComment, error) {
+	row := q.db.QueryRowContext(ctx, commentOnPost,
+		arg.UserID,
+		arg.PostID,
+		arg.Content,
+		arg.ParentCommentID,
+		arg.RootCommentID,
+		arg.Depth,
+	)
+	var i PostsComment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PostID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ParentCommentID,
+		&i.RootCommentID,
+		&i.Depth,
+	)
+	return i, err
+}
+
+const createCommentRevision = `-- name: CreateCommentRevision :exec
+INSERT INTO comment_revisions(comment_id, editor_id, content)
+VALUES (
+		$1,
+		$2,
+		$3
+		)
+`
+
+type CreateCommentRevisionParams struct {
+	CommentID uuid.UUID
+	EditorID  uuid.NullUUID
+	Content   string
 }
 
-func (q *Queries) CommentOnPost(ctx context.Context, arg CommentOnPostParams) error {
-	_, err := q.db.ExecContext(ctx, commentOnPost, arg.UserID, arg.PostID, arg.Content)
+func (q *Queries) CreateCommentRevision(ctx context.Context, arg CreateCommentRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createCommentRevision, arg.CommentID, arg.EditorID, arg.Content)
 	return err
 }
 
 const createPost = `-- name: CreatePost :one
-INSERT INTO posts(user_id, content, media_urls, visibility)
+INSERT INTO posts(user_id, content, media_urls, visibility, workout_id, program_id, repost_of)
 VALUES (
 		$1,
 		$2,
 		$3,
-		$4
+		$4,
+		$5,
+		$6,
+		$7
 		)
-RETURNING id, user_id, content, media_urls, visibility, like_count, comment_count, created_at, updated_at
+RETURNING id, user_id, content, media_urls, visibility, like_count, comment_count, created_at, updated_at, edited_at, deleted_at, workout_id, program_id, repost_of
 `
 
 type CreatePostParams struct {
@@ -71,6 +121,9 @@ type CreatePostParams struct {
 	Content    string
 	MediaUrls  []string
 	Visibility string
+	WorkoutID  uuid.NullUUID
+	ProgramID  uuid.NullUUID
+	RepostOf   uuid.NullUUID
 }
 
 func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
@@ -79,6 +132,9 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		arg.Content,
 		pq.Array(arg.MediaUrls),
 		arg.Visibility,
+		arg.WorkoutID,
+		arg.ProgramID,
+		arg.RepostOf,
 	)
 	var i Post
 	err := row.Scan(
@@ -91,10 +147,45 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.CommentCount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.WorkoutID,
+		&i.ProgramID,
+		&i.RepostOf,
 	)
 	return i, err
 }
 
+const createPostRevision = `-- name: CreatePostRevision :exec
+INSERT INTO post_revisions(post_id, editor_id, content, media_urls, visibility)
+VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5
+		)
+`
+
+type CreatePostRevisionParams struct {
+	PostID     uuid.UUID
+	EditorID   uuid.NullUUID
+	Content    string
+	MediaUrls  []string
+	Visibility string
+}
+
+func (q *Queries) CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createPostRevision,
+		arg.PostID,
+		arg.EditorID,
+		arg.Content,
+		pq.Array(arg.MediaUrls),
+		arg.Visibility,
+	)
+	return err
+}
+
 const deleteUserPostLike = `-- name: DeleteUserPostLike :exec
 DELETE FROM posts_likes
 WHERE post_id = $1
@@ -111,19 +202,107 @@ func (q *Queries) DeleteUserPostLike(ctx context.Context, arg DeleteUserPostLike
 	return err
 }
 
+const getComment = `-- name: GetComment :one
+SELECT id, user_id, post_id, content, created_at, edited_at, deleted_at, parent_comment_id, root_comment_id, depth FROM posts_comments
+WHERE id = $1
+`
+
+func (q *Queries) GetComment(ctx context.Context, id uuid.UUID) (PostsComment, error) {
+	row := q.db.QueryRowContext(ctx, getComment, id)
+	var i PostsComment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PostID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ParentCommentID,
+		&i.RootCommentID,
+		&i.Depth,
+	)
+	return i, err
+}
+
+const getCommentRevisions = `-- name: GetCommentRevisions :many
+SELECT id, comment_id, editor_id, content, created_at FROM comment_revisions
+WHERE comment_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetCommentRevisions(ctx context.Context, commentID uuid.UUID) ([]CommentRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getCommentRevisions, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommentRevision
+	for rows.Next() {
+		var i CommentRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.CommentID,
+			&i.EditorID,
+			&i.Content,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getFollowedPosts = `-- name: GetFollowedPosts :many
 SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
 		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
-		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id) as comments_count,
-		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = $1) as user_liked
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = $1) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
 FROM posts 
 INNER JOIN user_follows ON posts.user_id = user_follows.followed_id
 INNER JOIN users ON posts.user_id = users.id
 WHERE user_follows.follower_id = $1
+AND user_follows.status = 'accepted'
+AND posts.deleted_at IS NULL
 AND posts.visibility IN ('public', 'followers')
-ORDER BY posts.created_at DESC
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = $1 AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = $1))
+AND NOT EXISTS (SELECT 1 FROM posts newer
+		INNER JOIN user_follows newer_follows ON newer.user_id = newer_follows.followed_id
+		WHERE newer_follows.follower_id = $1 AND newer_follows.status = 'accepted'
+		AND newer.repost_of = (CASE WHEN posts.content = '' AND posts.repost_of IS NOT NULL THEN posts.repost_of ELSE posts.id END)
+		AND newer.content = ''
+		AND newer.deleted_at IS NULL
+		AND newer.visibility IN ('public', 'followers')
+		AND (newer.created_at, newer.id) > (posts.created_at, posts.id)
+		AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = $1 AND muted_id = newer.user_id)
+		AND NOT EXISTS (SELECT 1 FROM user_blocks
+			WHERE (blocker_id = $1 AND blocked_id = newer.user_id)
+			OR (blocker_id = newer.user_id AND blocked_id = $1)))
+AND ($2::timestamp IS NULL
+	OR (posts.created_at, posts.id) < ($2::timestamp, $3::uuid))
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT $4
 `
 
+type GetFollowedPostsParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
 type GetFollowedPostsRow struct {
 	ID            uuid.UUID
 	AuthorID      uuid.UUID
@@ -135,10 +314,23 @@ type GetFollowedPostsRow struct {
 	LikeCount     int64
 	CommentsCount int64
 	UserLiked     bool
+	UpdatedAt     time.Time
+	EditedAt      sql.NullTime
+	WorkoutID     uuid.NullUUID
+	ProgramID     uuid.NullUUID
+	RepostOf      uuid.NullUUID
+	RepostsCount  int64
 }
 
-func (q *Queries) GetFollowedPosts(ctx context.Context, userID uuid.UUID) ([]GetFollowedPostsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFollowedPosts, userID)
+// An item shared by several followed users shows up once, as its newest
+// plain repost.
+func (q *Queries) GetFollowedPosts(ctx context.Context, arg GetFollowedPostsParams) ([]GetFollowedPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedPosts,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -157,6 +349,12 @@ func (q *Queries) GetFollowedPosts(ctx context.Context, userID uuid.UUID) ([]Get
 			&i.LikeCount,
 			&i.CommentsCount,
 			&i.UserLiked,
+			&i.UpdatedAt,
+			&i.EditedAt,
+			&i.WorkoutID,
+			&i.ProgramID,
+			&i.RepostOf,
+			&i.RepostsCount,
 		); err != nil {
 			return nil, err
 		}
@@ -187,10 +385,13 @@ func (q *Queries) GetLikeCount(ctx context.Context, postID uuid.UUID) (int64, er
 const getPost = `-- name: GetPost :one
 SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content, 
 		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
-		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id) as comments_count
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
 FROM posts
 LEFT JOIN users ON posts.user_id = users.id
 WHERE posts.id = $1
+AND posts.deleted_at IS NULL
 `
 
 type GetPostRow struct {
@@ -203,6 +404,12 @@ type GetPostRow struct {
 	Content       string
 	LikeCount     int64
 	CommentsCount int64
+	UpdatedAt     time.Time
+	EditedAt      sql.NullTime
+	WorkoutID     uuid.NullUUID
+	ProgramID     uuid.NullUUID
+	RepostOf      uuid.NullUUID
+	RepostsCount  int64
 }
 
 func (q *Queries) GetPost(ctx context.Context, id uuid.UUID) (GetPostRow, error) {
@@ -218,30 +425,65 @@ func (q *Queries) GetPost(ctx context.Context, id uuid.UUID) (GetPostRow, error)
 		&i.Content,
 		&i.LikeCount,
 		&i.CommentsCount,
+		&i.UpdatedAt,
+		&i.EditedAt,
+		&i.WorkoutID,
+		&i.ProgramID,
+		&i.RepostOf,
+		&i.RepostsCount,
 	)
 	return i, err
 }
 
 const getPostComments = `-- name: GetPostComments :many
-SELECT posts_comments.id, posts_comments.user_id, posts_comments.post_id, posts_comments.content, posts_comments.created_at, users.name as commenter_name
+SELECT posts_comments.id, posts_comments.user_id, posts_comments.post_id, posts_comments.content, posts_comments.created_at, posts_comments.edited_at, posts_comments.deleted_at, posts_comments.parent_comment_id, posts_comments.root_comment_id, posts_comments.depth, users.name as commenter_name,
+		(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.comment_id = posts_comments.id) as like_count,
+		EXISTS(SELECT 1 FROM comment_likes WHERE comment_likes.comment_id = posts_comments.id AND comment_likes.user_id = $1) as user_liked
 FROM posts_comments
 LEFT JOIN users ON posts_comments.user_id = users.id
-WHERE posts_comments.post_id = $1
-ORDER BY posts_comments.created_at
-LIMIT 50
+WHERE posts_comments.post_id = $2
+AND posts_comments.parent_comment_id IS NULL
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = posts_comments.user_id)
+		OR (blocker_id = posts_comments.user_id AND blocked_id = $1))
+AND ($3::timestamp IS NULL
+	OR (posts_comments.created_at, posts_comments.id) > ($3::timestamp, $4::uuid))
+ORDER BY posts_comments.created_at, posts_comments.id
+LIMIT $5
 `
 
+type GetPostCommentsParams struct {
+	ViewerID        uuid.NullUUID
+	PostID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
 type GetPostCommentsRow struct {
-	ID            uuid.UUID
-	UserID        uuid.UUID
-	PostID        uuid.UUID
-	Content       string
-	CreatedAt     time.Time
-	CommenterName sql.NullString
+	ID              uuid.UUID
+	UserID          uuid.NullUUID
+	PostID          uuid.UUID
+	Content         string
+	CreatedAt       time.Time
+	EditedAt        sql.NullTime
+	DeletedAt       sql.NullTime
+	ParentCommentID uuid.NullUUID
+	RootCommentID   uuid.NullUUID
+	Depth           int32
+	CommenterName   sql.NullString
+	LikeCount       int64
+	UserLiked       bool
 }
 
-func (q *Queries) GetPostComments(ctx context.Context, postID uuid.UUID) ([]GetPostCommentsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostComments, postID)
+func (q *Queries) GetPostComments(ctx context.Context, arg GetPostCommentsParams) ([]GetPostCommentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostComments,
+		arg.ViewerID,
+		arg.PostID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -255,7 +497,140 @@ func (q *Queries) GetPostComments(ctx context.Context, postID uuid.UUID) ([]GetP
 			&i.PostID,
 			&i.Content,
 			&i.CreatedAt,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.ParentCommentID,
+			&i.RootCommentID,
+			&i.Depth,
 			&i.CommenterName,
+			&i.LikeCount,
+			&i.UserLiked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostRevisions = `-- name: GetPostRevisions :many
+SELECT id, post_id, editor_id, content, media_urls, visibility, created_at FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetPostRevisions(ctx context.Context, postID uuid.UUID) ([]PostRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getPostRevisions, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PostRevision
+	for rows.Next() {
+		var i PostRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.PostID,
+			&i.EditorID,
+			&i.Content,
+			pq.Array(&i.MediaUrls),
+			&i.Visibility,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisiblePosts = `-- name: GetVisiblePosts :many
+SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
+		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = $1) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
+FROM posts
+INNER JOIN users ON posts.user_id = users.id
+WHERE posts.id = ANY($2::uuid[])
+AND posts.deleted_at IS NULL
+AND users.deletion_scheduled_at IS NULL
+AND (posts.user_id = $1
+	OR (posts.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = posts.user_id AND user_settings.profile_visibility = 'private'))
+	OR (posts.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = $1 AND followed_id = posts.user_id AND status = 'accepted')))
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = $1 AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = $1))
+`
+
+type GetVisiblePostsParams struct {
+	ViewerID uuid.NullUUID
+	Ids      []uuid.UUID
+}
+
+type GetVisiblePostsRow struct {
+	ID            uuid.UUID
+	AuthorID      uuid.UUID
+	AuthorName    string
+	CreatedAt     time.Time
+	Visibility    string
+	MediaUrls     []string
+	Content       string
+	LikeCount     int64
+	CommentsCount int64
+	UserLiked     bool
+	UpdatedAt     time.Time
+	EditedAt      sql.NullTime
+	WorkoutID     uuid.NullUUID
+	ProgramID     uuid.NullUUID
+	RepostOf      uuid.NullUUID
+	RepostsCount  int64
+}
+
+// The posts out of ids the viewer may see and didn't mute, reposted posts
+// are embedded with them.
+func (q *Queries) GetVisiblePosts(ctx context.Context, arg GetVisiblePostsParams) ([]GetVisiblePostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getVisiblePosts, arg.ViewerID, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVisiblePostsRow
+	for rows.Next() {
+		var i GetVisiblePostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AuthorID,
+			&i.AuthorName,
+			&i.CreatedAt,
+			&i.Visibility,
+			pq.Array(&i.MediaUrls),
+			&i.Content,
+			&i.LikeCount,
+			&i.CommentsCount,
+			&i.UserLiked,
+			&i.UpdatedAt,
+			&i.EditedAt,
+			&i.WorkoutID,
+			&i.ProgramID,
+			&i.RepostOf,
+			&i.RepostsCount,
 		); err != nil {
 			return nil, err
 		}
@@ -287,3 +662,152 @@ func (q *Queries) LikePost(ctx context.Context, arg LikePostParams) error {
 	_, err := q.db.ExecContext(ctx, likePost, arg.UserID, arg.PostID)
 	return err
 }
+
+const lockCommentForEdit = `-- name: LockCommentForEdit :one
+SELECT posts_comments.id, posts_comments.user_id, posts_comments.post_id, posts_comments.content, posts_comments.created_at, posts_comments.edited_at, posts_comments.deleted_at, posts_comments.parent_comment_id, posts_comments.root_comment_id, posts_comments.depth FROM posts_comments
+INNER JOIN posts ON posts_comments.post_id = posts.id
+WHERE posts_comments.id = $1
+AND posts_comments.deleted_at IS NULL
+AND posts.deleted_at IS NULL
+FOR UPDATE OF posts_comments
+`
+
+func (q *Queries) LockCommentForEdit(ctx context.Context, id uuid.UUID) (PostsComment, error) {
+	row := q.db.QueryRowContext(ctx, lockCommentForEdit, id)
+	var i PostsComment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PostID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ParentCommentID,
+		&i.RootCommentID,
+		&i.Depth,
+	)
+	return i, err
+}
+
+const lockPostForEdit = `-- name: LockPostForEdit :one
+SELECT id, user_id, content, media_urls, visibility, like_count, comment_count, created_at, updated_at, edited_at, deleted_at, workout_id, program_id, repost_of FROM posts
+WHERE id = $1
+AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) LockPostForEdit(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, lockPostForEdit, id)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Content,
+		pq.Array(&i.MediaUrls),
+		&i.Visibility,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.WorkoutID,
+		&i.ProgramID,
+		&i.RepostOf,
+	)
+	return i, err
+}
+
+const softDeleteComment = `-- name: SoftDeleteComment :exec
+UPDATE posts_comments
+SET deleted_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteComment(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteComment, id)
+	return err
+}
+
+const softDeletePost = `-- name: SoftDeletePost :exec
+UPDATE posts
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeletePost(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeletePost, id)
+	return err
+}
+
+const updateComment = `-- name: UpdateComment :one
+UPDATE posts_comments
+SET content = $2, edited_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, post_id, content, created_at, edited_at, deleted_at, parent_comment_id, root_comment_id, depth
+`
+
+type UpdateCommentParams struct {
+	ID      uuid.UUID
+	Content string
+}
+
+func (q *Queries) UpdateComment(ctx context.Context, arg UpdateCommentParams) (PostsComment, error) {
+	row := q.db.QueryRowContext(ctx, updateComment, arg.ID, arg.Content)
+	var i PostsComment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.PostID,
+		&i.Content,
+		&i.CreatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.ParentCommentID,
+		&i.RootCommentID,
+		&i.Depth,
+	)
+	return i, err
+}
+
+const updatePost = `-- name: UpdatePost :one
+UPDATE posts
+SET content = $2, media_urls = $3, visibility = $4, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, content, media_urls, visibility, like_count, comment_count, created_at, updated_at, edited_at, deleted_at, workout_id, program_id, repost_of
+`
+
+type UpdatePostParams struct {
+	ID         uuid.UUID
+	Content    string
+	MediaUrls  []string
+	Visibility string
+}
+
+func (q *Queries) UpdatePost(ctx context.Context, arg UpdatePostParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, updatePost,
+		arg.ID,
+		arg.Content,
+		pq.Array(arg.MediaUrls),
+		arg.Visibility,
+	)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Content,
+		pq.Array(&i.MediaUrls),
+		&i.Visibility,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.WorkoutID,
+		&i.ProgramID,
+		&i.RepostOf,
+	)
+	return i, err
+}
@@ -174,6 +174,66 @@ func (q *Queries) GetProgram(ctx context.Context, id uuid.UUID) (GetProgramRow,
 	return i, err
 }
 
+const getProgramCards = `-- name: GetProgramCards :many
+SELECT programs.id, programs.name, programs.user_id, programs.description, programs.media_urls, programs.visibility, users.name as author_name,
+		(SELECT COUNT(*) FROM program_days WHERE program_days.program_id = programs.id) as days_count,
+		(SELECT COUNT(*) FROM users_programs WHERE users_programs.program_id = programs.id) as subscribers_count,
+		ARRAY(SELECT tags.name FROM program_tags INNER JOIN tags ON program_tags.tag_id = tags.id
+			WHERE program_tags.program_id = programs.id ORDER BY tags.name)::text[] as tags
+FROM programs
+INNER JOIN users ON programs.user_id = users.id
+WHERE programs.id = ANY($1::uuid[])
+AND users.deletion_scheduled_at IS NULL
+`
+
+type GetProgramCardsRow struct {
+	ID               uuid.UUID
+	Name             string
+	UserID           uuid.UUID
+	Description      string
+	MediaUrls        []string
+	Visibility       string
+	AuthorName       string
+	DaysCount        int64
+	SubscribersCount int64
+	Tags             []string
+}
+
+// Program cards are embedded in posts sharing the programs.
+func (q *Queries) GetProgramCards(ctx context.Context, ids []uuid.UUID) ([]GetProgramCardsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getProgramCards, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetProgramCardsRow
+	for rows.Next() {
+		var i GetProgramCardsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.UserID,
+			&i.Description,
+			pq.Array(&i.MediaUrls),
+			&i.Visibility,
+			&i.AuthorName,
+			&i.DaysCount,
+			&i.SubscribersCount,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProgramDayLifts = `-- name: GetProgramDayLifts :many
 SELECT p.id, p.program_day_id, p.exercise_id, p.description, p.lift_order, p.sets, p.reps, p.created_at
 FROM program_lifts p
@@ -253,13 +313,30 @@ func (q *Queries) GetProgramDays(ctx context.Context, programID uuid.UUID) ([]Pr
 
 const getPrograms = `-- name: GetPrograms :many
 SELECT programs.id, programs.name, programs.user_id, programs.description, programs.media_urls, programs.visibility, programs.created_at, programs.updated_at, users.name as author_name
-FROM programs 
-LEFT JOIN users 
-ON programs.user_id = users.id
-ORDER BY programs.created_at DESC
-LIMIT 50
+FROM programs
+INNER JOIN users ON programs.user_id = users.id
+WHERE users.deletion_scheduled_at IS NULL
+AND (programs.user_id = $1
+	OR (programs.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = programs.user_id AND user_settings.profile_visibility = 'private'))
+	OR (programs.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = $1 AND followed_id = programs.user_id AND status = 'accepted')))
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = programs.user_id)
+		OR (blocker_id = programs.user_id AND blocked_id = $1))
+AND ($2::timestamp IS NULL
+	OR (programs.created_at, programs.id) < ($2::timestamp, $3::uuid))
+ORDER BY programs.created_at DESC, programs.id DESC
+LIMIT $4
 `
 
+type GetProgramsParams struct {
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
 type GetProgramsRow struct {
 	ID          uuid.UUID
 	Name        string
@@ -269,11 +346,17 @@ type GetProgramsRow struct {
 	Visibility  string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
-	AuthorName  sql.NullString
+	AuthorName  string
 }
 
-func (q *Queries) GetPrograms(ctx context.Context) ([]GetProgramsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPrograms)
+// The programs the viewer may see, newest first.
+func (q *Queries) GetPrograms(ctx context.Context, arg GetProgramsParams) ([]GetProgramsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPrograms,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -21,20 +21,52 @@ func (q *Queries) CleanExpiredRefreshTokens(ctx context.Context) error {
 	return err
 }
 
+const createAuthEvent = `-- name: CreateAuthEvent :exec
+INSERT INTO auth_events (user_id, event_type, details, ip_address)
+VALUES ($1, $2, $3, $4)
+`
+
+type CreateAuthEventParams struct {
+	UserID    uuid.NullUUID
+	EventType string
+	Details   string
+	IpAddress string
+}
+
+func (q *Queries) CreateAuthEvent(ctx context.Context, arg CreateAuthEventParams) error {
+	_, err := q.db.ExecContext(ctx, createAuthEvent,
+		arg.UserID,
+		arg.EventType,
+		arg.Details,
+		arg.IpAddress,
+	)
+	return err
+}
+
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
-VALUES ($1, $2, $3)
-RETURNING id, created_at, user_id, token_hash, expires_at
+INSERT INTO refresh_tokens (user_id, token_hash, expires_at, family_id, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, created_at, user_id, token_hash, expires_at, family_id, rotated_at, last_used_at, user_agent, ip_address
 `
 
 type CreateRefreshTokenParams struct {
 	UserID    uuid.UUID
 	TokenHash string
 	ExpiresAt time.Time
+	FamilyID  uuid.UUID
+	UserAgent string
+	IpAddress string
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.UserID, arg.TokenHash, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.UserID,
+		arg.TokenHash,
+		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.UserAgent,
+		arg.IpAddress,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.ID,
@@ -42,6 +74,11 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.UserID,
 		&i.TokenHash,
 		&i.ExpiresAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
 	)
 	return i, err
 }
@@ -64,8 +101,64 @@ func (q *Queries) DeleteUserRefreshToken(ctx context.Context, userID uuid.UUID)
 	return err
 }
 
+const deleteUserSession = `-- name: DeleteUserSession :execrows
+DELETE FROM refresh_tokens
+WHERE family_id = $1
+AND user_id = $2
+`
+
+type DeleteUserSessionParams struct {
+	FamilyID uuid.UUID
+	UserID   uuid.UUID
+}
+
+func (q *Queries) DeleteUserSession(ctx context.Context, arg DeleteUserSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUserSession, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAuthEvents = `-- name: GetAuthEvents :many
+SELECT id, user_id, event_type, details, created_at, ip_address FROM auth_events
+WHERE ($1::uuid IS NULL OR user_id = $1)
+ORDER BY created_at DESC
+LIMIT 100
+`
+
+func (q *Queries) GetAuthEvents(ctx context.Context, userID uuid.NullUUID) ([]AuthEvent, error) {
+	rows, err := q.db.QueryContext(ctx, getAuthEvents, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuthEvent
+	for rows.Next() {
+		var i AuthEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.EventType,
+			&i.Details,
+			&i.CreatedAt,
+			&i.IpAddress,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT id, created_at, user_id, token_hash, expires_at FROM refresh_tokens WHERE token_hash=$1
+SELECT id, created_at, user_id, token_hash, expires_at, family_id, rotated_at, last_used_at, user_agent, ip_address FROM refresh_tokens WHERE token_hash=$1
 `
 
 func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
@@ -77,6 +170,74 @@ func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (Refres
 		&i.UserID,
 		&i.TokenHash,
 		&i.ExpiresAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.LastUsedAt,
+		&i.UserAgent,
+		&i.IpAddress,
 	)
 	return i, err
 }
+
+const getUserSessions = `-- name: GetUserSessions :many
+SELECT t.family_id,
+		(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id)::timestamp AS session_created_at,
+		t.last_used_at, t.user_agent, t.ip_address, t.expires_at
+FROM refresh_tokens t
+WHERE t.user_id = $1
+AND t.rotated_at IS NULL
+AND t.expires_at > NOW()
+ORDER BY t.last_used_at DESC
+`
+
+type GetUserSessionsRow struct {
+	FamilyID         uuid.UUID
+	SessionCreatedAt time.Time
+	LastUsedAt       time.Time
+	UserAgent        string
+	IpAddress        string
+	ExpiresAt        time.Time
+}
+
+func (q *Queries) GetUserSessions(ctx context.Context, userID uuid.UUID) ([]GetUserSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserSessionsRow
+	for rows.Next() {
+		var i GetUserSessionsRow
+		if err := rows.Scan(
+			&i.FamilyID,
+			&i.SessionCreatedAt,
+			&i.LastUsedAt,
+			&i.UserAgent,
+			&i.IpAddress,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens SET rotated_at = NOW(), last_used_at = NOW()
+WHERE id=$1 AND rotated_at IS NULL
+`
+
+func (q *Queries) RotateRefreshToken(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, rotateRefreshToken, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -7,11 +7,34 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const changeUserEmail = `-- name: ChangeUserEmail :execrows
+UPDATE users
+SET email = $2,
+email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+`
+
+type ChangeUserEmailParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+func (q *Queries) ChangeUserEmail(ctx context.Context, arg ChangeUserEmailParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, changeUserEmail, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (id, created_at, updated_at, name, email, bio, hashed_password)
 VALUES (
@@ -23,7 +46,7 @@ VALUES (
 		$3,
 		$4
 )
-RETURNING id, created_at, updated_at, name, email, bio, hashed_password, premium
+RETURNING id, created_at, updated_at, name, email, bio, hashed_password, premium, role, email_verified_at, deletion_scheduled_at
 `
 
 type CreateUserParams struct {
@@ -50,88 +73,30 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Bio,
 		&i.HashedPassword,
 		&i.Premium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
-const followUser = `-- name: FollowUser :exec
-INSERT INTO user_follows(follower_id, followed_id)
-VALUES (
-		$1,
-		$2
-		)
-`
-
-type FollowUserParams struct {
-	FollowerID uuid.UUID
-	FollowedID uuid.UUID
-}
-
-func (q *Queries) FollowUser(ctx context.Context, arg FollowUserParams) error {
-	_, err := q.db.ExecContext(ctx, followUser, arg.FollowerID, arg.FollowedID)
-	return err
-}
-
-const getFollowedUsers = `-- name: GetFollowedUsers :many
-SELECT users.id, users.name, users.created_at, users.updated_at, users.bio, users.premium
-FROM users
-INNER JOIN user_follows ON users.id = user_follows.followed_id
-WHERE user_follows.follower_id = $1
-`
-
-type GetFollowedUsersRow struct {
-	ID        uuid.UUID
-	Name      string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Bio       string
-	Premium   bool
-}
-
-func (q *Queries) GetFollowedUsers(ctx context.Context, followerID uuid.UUID) ([]GetFollowedUsersRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFollowedUsers, followerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetFollowedUsersRow
-	for rows.Next() {
-		var i GetFollowedUsersRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-			&i.Bio,
-			&i.Premium,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
-
 const getUser = `-- name: GetUser :one
-SELECT id, created_at, updated_at, name, email, bio, premium
+SELECT id, created_at, updated_at, name, email, bio, premium, role, email_verified_at, deletion_scheduled_at
 FROM users
 WHERE id = $1
 `
 
 type GetUserRow struct {
-	ID        uuid.UUID
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
-	Email     string
-	Bio       string
-	Premium   bool
+	ID                  uuid.UUID
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	Name                string
+	Email               string
+	Bio                 string
+	Premium             bool
+	Role                string
+	EmailVerifiedAt     sql.NullTime
+	DeletionScheduledAt sql.NullTime
 }
 
 func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (GetUserRow, error) {
@@ -145,12 +110,15 @@ func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (GetUserRow, error)
 		&i.Email,
 		&i.Bio,
 		&i.Premium,
+		&i.Role,
+		&i.EmailVerifiedAt,
+		&i.DeletionScheduledAt,
 	)
 	return i, err
 }
 
 const getUserLogin = `-- name: GetUserLogin :one
-SELECT id, created_at, updated_at, name, email, bio, premium, hashed_password
+SELECT id, created_at, updated_at, name, email, bio, premium, hashed_password, role
 FROM users
 WHERE email = $1
 `
@@ -164,6 +132,7 @@ type GetUserLoginRow struct {
 	Bio            string
 	Premium        bool
 	HashedPassword string
+	Role           string
 }
 
 func (q *Queries) GetUserLogin(ctx context.Context, email string) (GetUserLoginRow, error) {
@@ -178,10 +147,67 @@ func (q *Queries) GetUserLogin(ctx context.Context, email string) (GetUserLoginR
 		&i.Bio,
 		&i.Premium,
 		&i.HashedPassword,
+		&i.Role,
 	)
 	return i, err
 }
 
+const getUsersByNames = `-- name: GetUsersByNames :many
+SELECT id, lower(name)::text AS name
+FROM users
+WHERE lower(name) = ANY($1::text[])
+AND deletion_scheduled_at IS NULL
+`
+
+type GetUsersByNamesRow struct {
+	ID   uuid.UUID
+	Name string
+}
+
+func (q *Queries) GetUsersByNames(ctx context.Context, names []string) ([]GetUsersByNamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByNames, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByNamesRow
+	for rows.Next() {
+		var i GetUsersByNamesRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEmailVerified = `-- name: MarkEmailVerified :execrows
+UPDATE users
+SET email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+AND email = $2
+`
+
+type MarkEmailVerifiedParams struct {
+	ID    uuid.UUID
+	Email string
+}
+
+func (q *Queries) MarkEmailVerified(ctx context.Context, arg MarkEmailVerifiedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markEmailVerified, arg.ID, arg.Email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateBio = `-- name: UpdateBio :exec
 UPDATE users
 SET bio = $2,
@@ -198,3 +224,59 @@ func (q *Queries) UpdateBio(ctx context.Context, arg UpdateBioParams) error {
 	_, err := q.db.ExecContext(ctx, updateBio, arg.ID, arg.Bio)
 	return err
 }
+
+const updateUserPassword = `-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserPasswordParams struct {
+	ID             uuid.UUID
+	HashedPassword string
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserPassword, arg.ID, arg.HashedPassword)
+	return err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :exec
+UPDATE users
+SET name = $2,
+bio = $3,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserProfileParams struct {
+	ID   uuid.UUID
+	Name string
+	Bio  string
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserProfile, arg.ID, arg.Name, arg.Bio)
+	return err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :execrows
+UPDATE users
+SET role = $2,
+updated_at = NOW()
+WHERE id = $1
+`
+
+type UpdateUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
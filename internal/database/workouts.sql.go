@@ -7,8 +7,11 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createWorkout = `-- name: CreateWorkout :one
@@ -127,11 +130,26 @@ func (q *Queries) GetUsersLiftsByExercise(ctx context.Context, exerciseID uuid.U
 const getUsersWorkouts = `-- name: GetUsersWorkouts :many
 SELECT id, user_id, program_day_id, created_at FROM workouts
 WHERE user_id = $1
-ORDER BY created_at DESC
+AND ($2::timestamp IS NULL
+	OR (created_at, id) < ($2::timestamp, $3::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT $4
 `
 
-func (q *Queries) GetUsersWorkouts(ctx context.Context, userID uuid.UUID) ([]Workout, error) {
-	rows, err := q.db.QueryContext(ctx, getUsersWorkouts, userID)
+type GetUsersWorkoutsParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) GetUsersWorkouts(ctx context.Context, arg GetUsersWorkoutsParams) ([]Workout, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersWorkouts,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -213,3 +231,75 @@ func (q *Queries) GetWorkoutLifts(ctx context.Context, workoutID uuid.UUID) ([]U
 	}
 	return items, nil
 }
+
+const getWorkoutSummaries = `-- name: GetWorkoutSummaries :many
+SELECT workouts.id as workout_id, workouts.user_id, workouts.created_at, program_days.name as day_name, programs.id as program_id, programs.name as program_name, programs.user_id as program_author_id, programs.visibility as program_visibility,
+		exercises.name as exercise_name, users_lifts.weight, users_lifts.sets, users_lifts.reps,
+		COALESCE(users_lifts.weight > (SELECT MAX(earlier.weight) FROM users_lifts earlier
+			INNER JOIN workouts earlier_workouts ON earlier.workout_id = earlier_workouts.id
+			WHERE earlier.user_id = users_lifts.user_id AND earlier.exercise_id = users_lifts.exercise_id
+			AND earlier_workouts.created_at < workouts.created_at), false)::boolean as pr
+FROM workouts
+INNER JOIN program_days ON workouts.program_day_id = program_days.id
+INNER JOIN programs ON program_days.program_id = programs.id
+LEFT JOIN users_lifts ON users_lifts.workout_id = workouts.id
+LEFT JOIN exercises ON users_lifts.exercise_id = exercises.id
+WHERE workouts.id = ANY($1::uuid[])
+ORDER BY workouts.id, users_lifts.lift_order
+`
+
+type GetWorkoutSummariesRow struct {
+	WorkoutID         uuid.UUID
+	UserID            uuid.UUID
+	CreatedAt         time.Time
+	DayName           string
+	ProgramID         uuid.UUID
+	ProgramName       string
+	ProgramAuthorID   uuid.UUID
+	ProgramVisibility string
+	ExerciseName      sql.NullString
+	Weight            sql.NullInt32
+	Sets              sql.NullInt32
+	Reps              sql.NullInt32
+	Pr                bool
+}
+
+// One row per lift of the workouts, workouts without lifts get one row
+// without a lift. A lift is a PR when its weight beats every earlier lift of
+// the exercise by the user, the first lift of an exercise isn't one.
+func (q *Queries) GetWorkoutSummaries(ctx context.Context, ids []uuid.UUID) ([]GetWorkoutSummariesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkoutSummaries, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkoutSummariesRow
+	for rows.Next() {
+		var i GetWorkoutSummariesRow
+		if err := rows.Scan(
+			&i.WorkoutID,
+			&i.UserID,
+			&i.CreatedAt,
+			&i.DayName,
+			&i.ProgramID,
+			&i.ProgramName,
+			&i.ProgramAuthorID,
+			&i.ProgramVisibility,
+			&i.ExerciseName,
+			&i.Weight,
+			&i.Sets,
+			&i.Reps,
+			&i.Pr,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// HandleDeleteAccount schedules the account for deletion. The password is
+// asked again so a stolen access token can't wipe the account.
+func (h *UserHandler) HandleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		Password string `json:"password"`
+		// Code is a two-factor or recovery code, accepted instead of the
+		// password
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	var ok bool
+	if req.Code != "" {
+		_, ok = confirmTwoFactorCode(w, r, h.DB, h.LoginGuard, h.TwoFactorService, userId, req.Code)
+	} else {
+		_, ok = confirmPassword(w, r, h.DB, h.LoginGuard, userId, req.Password)
+	}
+	if !ok {
+		return
+	}
+	deleteAt, err := h.AccountService.ScheduleDeletion(r.Context(), userId)
+	if errors.Is(err, services.ErrDeletionScheduled) {
+		respondWithError(w, http.StatusConflict, "Account deletion is already scheduled", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to schedule deletion", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]time.Time{"deletion_scheduled_at": deleteAt})
+}
+
+func (h *UserHandler) HandleRestoreAccount(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	err := h.AccountService.CancelDeletion(r.Context(), userId)
+	if errors.Is(err, services.ErrDeletionNotScheduled) {
+		respondWithError(w, http.StatusConflict, "Account deletion is not scheduled", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to restore account", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Account restored"})
+}
+
+func (h *UserHandler) HandleExportAccount(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	// buffered so an error can still be reported as JSON
+	var buf bytes.Buffer
+	if err := h.AccountService.Export(r.Context(), userId, &buf); err != nil {
+		respondWithError(w, 500, "failed to export data", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="fitterby-export-%s.zip"`, time.Now().UTC().Format("2006-01-02")))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(200)
+	w.Write(buf.Bytes())
+}
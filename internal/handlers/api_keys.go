@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	// Key is only returned once, when the key is created.
+	Key string `json:"key,omitempty"`
+}
+
+func apiKeyFromDB(k database.ApiKey) APIKey {
+	key := APIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.KeyPrefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+	}
+	if k.LastUsedAt.Valid {
+		key.LastUsedAt = &k.LastUsedAt.Time
+	}
+	if k.ExpiresAt.Valid {
+		key.ExpiresAt = &k.ExpiresAt.Time
+	}
+	return key
+}
+
+func (h *AuthHandler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.Name == "" || len(req.Scopes) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Name and scopes are required", errors.New("some fields are empty"))
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			respondWithError(w, http.StatusBadRequest, "Unknown scope: "+scope, errors.New("invalid scope"))
+			return
+		}
+	}
+	var expiresAt sql.NullTime
+	if req.ExpiresAt != nil {
+		if req.ExpiresAt.Before(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "Expiry must be in the future", errors.New("expired"))
+			return
+		}
+		expiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+	}
+	key, apiKey, err := h.APIKeyService.Create(r.Context(), userId, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		respondWithError(w, 500, "failed to create api key", err)
+		return
+	}
+	resp := apiKeyFromDB(apiKey)
+	resp.Key = key
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (h *AuthHandler) HandleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	keys, err := h.DB.GetUserAPIKeys(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get api keys", err)
+		return
+	}
+	var resp struct {
+		Keys []APIKey `json:"keys"`
+	}
+	for _, k := range keys {
+		resp.Keys = append(resp.Keys, apiKeyFromDB(k))
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *AuthHandler) HandleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	keyId, err := uuid.Parse(r.PathValue("key_id"))
+	if err != nil {
+		respondWithError(w, 400, "wrong api key id", err)
+		return
+	}
+	err = h.APIKeyService.Delete(r.Context(), userId, keyId)
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		respondWithError(w, 404, "api key not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to delete api key", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "API key revoked"})
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"log"
+	"net"
 	"net/http"
+	"net/mail"
+	"sync"
 
 	"github.com/alexedwards/argon2id"
 	"github.com/google/uuid"
@@ -14,9 +19,16 @@ import (
 )
 
 type AuthHandler struct {
-	DB           *database.Queries
-	JWTConfig    *auth.JWTConfig
-	TokenService services.TokenService
+	DB                       *database.Queries
+	JWTConfig                *auth.JWTConfig
+	TokenService             services.TokenService
+	PasswordResetService     *services.PasswordResetService
+	EmailVerificationService *services.EmailVerificationService
+	TwoFactorService         *services.TwoFactorService
+	LoginGuard               *services.LoginGuard
+	APIKeyService            *services.APIKeyService
+	OAuthService             *services.OAuthService
+	Cookies                  CookieConfig
 }
 
 type SignupRequest struct {
@@ -35,6 +47,14 @@ type SignupResponse struct {
 	Name           string    `json:"name"`
 	Email          string    `json:"email"`
 	TokenResoponse *TokenResoponse
+	// CSRFToken replaces the tokens for cookie sessions.
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
 }
 
 type RefreshTokenRequest struct {
@@ -50,20 +70,41 @@ type TokenResoponse struct {
 
 func (h *AuthHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
 	var req RefreshTokenRequest
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&req); err != nil {
-		respondWithJSON(w, http.StatusBadRequest, TokenResoponse{})
-		return
+	cookie, cookieErr := r.Cookie(middleware.RefreshTokenCookie)
+	fromCookie := h.Cookies.Enabled && cookieErr == nil
+	if fromCookie {
+		if !middleware.ValidCSRF(r) {
+			respondWithError(w, http.StatusForbidden, "CSRF token missing or invalid", errors.New("csrf mismatch"))
+			return
+		}
+		req.RefreshToken = cookie.Value
+	} else {
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&req); err != nil {
+			respondWithJSON(w, http.StatusBadRequest, TokenResoponse{})
+			return
+		}
 	}
 	if req.RefreshToken == "" {
 		respondWithJSON(w, http.StatusBadRequest, TokenResoponse{})
 		return
 	}
-	accessToken, refreshToken, err := h.TokenService.ValidateAndRefreshTokens(r.Context(), req.RefreshToken)
+	accessToken, refreshToken, err := h.TokenService.ValidateAndRefreshTokens(r.Context(), req.RefreshToken, clientInfoFromRequest(r))
 	if err != nil {
+		if fromCookie {
+			h.clearSessionCookies(w)
+		}
 		respondWithJSON(w, http.StatusUnauthorized, TokenResoponse{})
 		return
 	}
+	if fromCookie {
+		csrfToken := h.setSessionCookies(w, accessToken, refreshToken)
+		respondWithJSON(w, http.StatusOK, map[string]any{
+			"csrf_token": csrfToken,
+			"expires_in": int64(h.TokenService.JWTConfig.AccessTokenExpiry.Seconds()),
+		})
+		return
+	}
 	response := TokenResoponse{
 		RefreshToken: refreshToken,
 		AccessToken:  accessToken,
@@ -80,9 +121,36 @@ func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 500, "failed to logout", err)
 		return
 	}
+	if h.Cookies.Enabled {
+		h.clearSessionCookies(w)
+	}
 	respondWithJSON(w, 200, map[string]string{"message": "Logged out successfully"})
 }
 
+func clientInfoFromRequest(r *http.Request) services.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return services.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
+	}
+}
+
+func validEmail(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := HashPassword(uuid.NewString())
+	if err != nil {
+		panic(err)
+	}
+	return hash
+})
+
 func HashPassword(password string) (string, error) {
 	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
 	if err != nil {
@@ -109,6 +177,10 @@ func (h *AuthHandler) HandleSignup(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "All fields are required", errors.New("some fields are empty"))
 		return
 	}
+	if !validEmail(req.Email) {
+		respondWithError(w, http.StatusBadRequest, "Invalid email", errors.New("malformed email"))
+		return
+	}
 	if len(req.Password) < 6 {
 		respondWithError(w, http.StatusBadRequest, "Weak password", errors.New("password should be at least 6 charachters long"))
 		return
@@ -131,23 +203,11 @@ func (h *AuthHandler) HandleSignup(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusInternalServerError, "Failed to create user account", err)
 		return
 	}
-	accessToken, refreshToken, err := h.TokenService.GenerateTokens(r.Context(), user.ID.String(), "user_type", user.Email)
-	if err != nil {
-		respondWithError(w, 500, "failed to create tokens", err)
-		return
-	}
-	tokenResoponse := TokenResoponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		TokenType:    "Bearer",
-		ExpiresIn:    int64(h.TokenService.JWTConfig.AccessTokenExpiry.Seconds()),
+	if err := h.EmailVerificationService.SendVerification(r.Context(), user.ID); err != nil {
+		// the user can ask for a new email later
+		log.Printf("failed to send verification email: %v", err)
 	}
-	respondWithJSON(w, http.StatusCreated, SignupResponse{
-		ID:             user.ID,
-		Name:           user.Name,
-		Email:          user.Email,
-		TokenResoponse: &tokenResoponse,
-	})
+	h.respondWithTokens(w, r, http.StatusCreated, h.wantsCookies(r), user.ID, user.Name, user.Email, user.Role)
 }
 
 func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
@@ -160,28 +220,85 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, http.StatusBadRequest, "All fields are required", errors.New("some fields are empty"))
 		return
 	}
-	user, err := h.DB.GetUserLogin(r.Context(), req.Email)
+	ip := clientInfoFromRequest(r).IPAddress
+	release, ok := checkLoginAttempts(w, r, h.LoginGuard, req.Email, ip)
+	defer release()
+	if !ok {
+		return
+	}
 
-	if err != nil {
-		respondWithError(w, 422, "Wrong email", err)
+	user, err := h.DB.GetUserLogin(r.Context(), req.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		respondWithError(w, 500, "failed to log in", err)
+		return
+	}
+	userExists := err == nil
+	hash := user.HashedPassword
+	if !userExists {
+		// check against a dummy hash so the response time doesn't reveal unknown emails
+		hash = dummyPasswordHash()
+	}
+	valid, err := CheckPasswordHash(req.Password, hash)
+	if err != nil || !valid || !userExists {
+		userId := uuid.NullUUID{UUID: user.ID, Valid: userExists}
+		if err := h.LoginGuard.RecordFailure(r.Context(), req.Email, ip, userId); err != nil {
+			log.Printf("failed to record failed login: %v", err)
+		}
+		respondWithError(w, 422, "Wrong email or password", errors.New("invalid credentials"))
 		return
 	}
+	h.completeLogin(w, r, h.wantsCookies(r), user.ID, user.Name, user.Email, user.Role)
+}
 
-	valid, err := CheckPasswordHash(req.Password, user.HashedPassword)
+// completeLogin hands out tokens, or a second factor challenge when the user
+// has two-factor authentication enabled. Failed logins are only forgotten
+// once the tokens are out, not after the password alone.
+func (h *AuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, cookies bool, userId uuid.UUID, name, email, role string) {
+	enabled, err := h.TwoFactorService.IsEnabled(r.Context(), userId)
 	if err != nil {
-		respondWithError(w, 422, "Wrong password", err)
+		respondWithError(w, 500, "failed to check two-factor authentication", err)
 		return
 	}
-
-	if !valid {
-		respondWithError(w, 422, "Wrong password", errors.New("wrong password"))
+	if enabled {
+		mfaToken, err := h.TwoFactorService.CreateChallenge(r.Context(), userId)
+		if err != nil {
+			respondWithError(w, 500, "failed to create mfa challenge", err)
+			return
+		}
+		respondWithJSON(w, http.StatusOK, MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+			ExpiresIn:   int64(h.TwoFactorService.ChallengeExpiry.Seconds()),
+		})
 		return
 	}
 
-	accessToken, refreshToken, err := h.TokenService.GenerateTokens(r.Context(), user.ID.String(), "user_type", user.Email)
+	if h.respondWithTokens(w, r, http.StatusOK, cookies, userId, name, email, role) {
+		h.recordLoginSuccess(r, email)
+	}
+}
+
+func (h *AuthHandler) recordLoginSuccess(r *http.Request, email string) {
+	if err := h.LoginGuard.RecordSuccess(r.Context(), email); err != nil {
+		log.Printf("failed to reset login attempts: %v", err)
+	}
+}
+
+// respondWithTokens reports whether the tokens were handed out.
+func (h *AuthHandler) respondWithTokens(w http.ResponseWriter, r *http.Request, code int, cookies bool, userId uuid.UUID, name, email, role string) bool {
+	accessToken, refreshToken, err := h.TokenService.GenerateTokens(r.Context(), userId.String(), role, email, clientInfoFromRequest(r))
 	if err != nil {
 		respondWithError(w, 500, "failed to create tokens", err)
-		return
+		return false
+	}
+	if cookies {
+		respondWithJSON(w, code, SignupResponse{
+			ID:        userId,
+			Name:      name,
+			Email:     email,
+			CSRFToken: h.setSessionCookies(w, accessToken, refreshToken),
+		})
+		return true
 	}
 	tokenResoponse := TokenResoponse{
 		AccessToken:  accessToken,
@@ -189,10 +306,11 @@ func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 		TokenType:    "Bearer",
 		ExpiresIn:    int64(h.TokenService.JWTConfig.AccessTokenExpiry.Seconds()),
 	}
-	respondWithJSON(w, http.StatusOK, SignupResponse{
-		ID:             user.ID,
-		Name:           user.Name,
-		Email:          user.Email,
+	respondWithJSON(w, code, SignupResponse{
+		ID:             userId,
+		Name:           name,
+		Email:          email,
 		TokenResoponse: &tokenResoponse,
 	})
+	return true
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuthEvent struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    *uuid.UUID `json:"user_id"`
+	EventType string     `json:"event_type"`
+	Details   string     `json:"details"`
+	IPAddress string     `json:"ip_address"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (h *AuthHandler) HandleGetAuthEvents(w http.ResponseWriter, r *http.Request) {
+	var userId uuid.NullUUID
+	if userIdString := r.URL.Query().Get("user_id"); userIdString != "" {
+		id, err := uuid.Parse(userIdString)
+		if err != nil {
+			respondWithError(w, 400, "wrong user id format", err)
+			return
+		}
+		userId = uuid.NullUUID{UUID: id, Valid: true}
+	}
+	events, err := h.DB.GetAuthEvents(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get auth events", err)
+		return
+	}
+	var resp struct {
+		Events []AuthEvent `json:"events"`
+	}
+	for _, e := range events {
+		event := AuthEvent{
+			ID:        e.ID,
+			EventType: e.EventType,
+			Details:   e.Details,
+			IPAddress: e.IpAddress,
+			CreatedAt: e.CreatedAt,
+		}
+		if e.UserID.Valid {
+			event.UserID = &e.UserID.UUID
+		}
+		resp.Events = append(resp.Events, event)
+	}
+	respondWithJSON(w, 200, resp)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type BlockedUser struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	AvatarURL string    `json:"avatar_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type BlockedList struct {
+	Users      []BlockedUser `json:"users"`
+	NextCursor *string       `json:"next_cursor"`
+}
+
+func (h *UserHandler) HandleBlockUser(w http.ResponseWriter, r *http.Request) {
+	h.changeBlock(w, r, h.BlockService.Block, "User blocked")
+}
+
+func (h *UserHandler) HandleUnblockUser(w http.ResponseWriter, r *http.Request) {
+	h.changeBlock(w, r, h.BlockService.Unblock, "User unblocked")
+}
+
+func (h *UserHandler) HandleMuteUser(w http.ResponseWriter, r *http.Request) {
+	h.changeBlock(w, r, h.BlockService.Mute, "User muted")
+}
+
+func (h *UserHandler) HandleUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	h.changeBlock(w, r, h.BlockService.Unmute, "User unmuted")
+}
+
+func (h *UserHandler) changeBlock(w http.ResponseWriter, r *http.Request, change func(context.Context, uuid.UUID, uuid.UUID) error, message string) {
+	userId := userIdFromContext(r)
+	targetId, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	err = change(r.Context(), userId, targetId)
+	switch {
+	case errors.Is(err, services.ErrCannotBlockSelf):
+		respondWithError(w, http.StatusBadRequest, "You can't block or mute yourself", err)
+		return
+	case errors.Is(err, services.ErrUserNotFound):
+		respondWithError(w, 404, "no user found", err)
+		return
+	case errors.Is(err, services.ErrNotBlocked), errors.Is(err, services.ErrNotMuted):
+		respondWithError(w, 404, err.Error(), err)
+		return
+	case err != nil:
+		respondWithError(w, 500, "failed to update block list", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": message})
+}
+
+func (h *UserHandler) HandleGetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	rows, err := h.DB.GetBlockedUsers(r.Context(), database.GetBlockedUsersParams{
+		BlockerID:       userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get blocked users", err)
+		return
+	}
+	rows, next := paginate(rows, page, func(row database.GetBlockedUsersRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.ID
+	})
+	users := make([]BlockedUser, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, BlockedUser{ID: row.ID, Name: row.Name, AvatarURL: row.AvatarUrl, CreatedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, 200, BlockedList{Users: users, NextCursor: next})
+}
+
+func (h *UserHandler) HandleGetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	rows, err := h.DB.GetMutedUsers(r.Context(), database.GetMutedUsersParams{
+		MuterID:         userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get muted users", err)
+		return
+	}
+	rows, next := paginate(rows, page, func(row database.GetMutedUsersRow) (time.Time, uuid.UUID) {
+		return row.CreatedAt, row.ID
+	})
+	users := make([]BlockedUser, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, BlockedUser{ID: row.ID, Name: row.Name, AvatarURL: row.AvatarUrl, CreatedAt: row.CreatedAt})
+	}
+	respondWithJSON(w, 200, BlockedList{Users: users, NextCursor: next})
+}
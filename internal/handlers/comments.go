@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type Comment struct {
+	ID              uuid.UUID  `json:"id"`
+	PostID          uuid.UUID  `json:"post_id"`
+	UserID          *uuid.UUID `json:"user_id"`
+	AuthorName      string     `json:"author_name"`
+	ParentCommentID *uuid.UUID `json:"parent_comment_id"`
+	Content         string     `json:"content"`
+	CreatedAt       time.Time  `json:"created_at"`
+	EditedAt        *time.Time `json:"edited_at"`
+	Deleted         bool       `json:"deleted"`
+	Depth           int        `json:"depth"`
+	LikesCount      int        `json:"likes_count"`
+	Liked           bool       `json:"liked"`
+	Replies         []Comment  `json:"replies"`
+	// RepliesCursor is set on top level comments with more replies than
+	// listed, GET /posts/comments/{comment_id}/replies returns the rest.
+	RepliesCursor *string `json:"replies_cursor,omitempty"`
+}
+
+// repliesPerThread caps the replies listed with each top level comment.
+const repliesPerThread = 20
+
+func commentFromDB(c database.PostsComment) Comment {
+	return Comment{
+		ID:              c.ID,
+		PostID:          c.PostID,
+		UserID:          optionalId(c.UserID),
+		ParentCommentID: optionalId(c.ParentCommentID),
+		Content:         c.Content,
+		CreatedAt:       c.CreatedAt,
+		EditedAt:        editedAt(c.EditedAt),
+		Depth:           int(c.Depth),
+		Replies:         []Comment{},
+	}
+}
+
+// commentFromRow converts a comment of a thread listing. Deleted comments
+// keep their place in the thread without their content.
+func commentFromRow(row database.GetPostCommentsRow) Comment {
+	c := Comment{
+		ID:              row.ID,
+		PostID:          row.PostID,
+		UserID:          optionalId(row.UserID),
+		AuthorName:      row.CommenterName.String,
+		ParentCommentID: optionalId(row.ParentCommentID),
+		Content:         row.Content,
+		CreatedAt:       row.CreatedAt,
+		EditedAt:        editedAt(row.EditedAt),
+		Depth:           int(row.Depth),
+		LikesCount:      int(row.LikeCount),
+		Liked:           row.UserLiked,
+		Replies:         []Comment{},
+	}
+	if row.DeletedAt.Valid {
+		c.Deleted = true
+		c.Content = ""
+	}
+	return c
+}
+
+// commentTree hangs the replies, ordered oldest first, below the top level
+// comments. Replies whose parent isn't listed, because its author is
+// blocked, are left out together with their own replies.
+func commentTree(roots []Comment, replies []Comment) []Comment {
+	children := make(map[uuid.UUID][]Comment)
+	for _, reply := range replies {
+		if reply.ParentCommentID != nil {
+			children[*reply.ParentCommentID] = append(children[*reply.ParentCommentID], reply)
+		}
+	}
+	var attach func(c Comment) Comment
+	attach = func(c Comment) Comment {
+		for _, child := range children[c.ID] {
+			c.Replies = append(c.Replies, attach(child))
+		}
+		return c
+	}
+	tree := make([]Comment, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, attach(root))
+	}
+	return tree
+}
+
+// limitReplies keeps the first perThread replies of each thread, out of rows
+// fetched with one more per thread, and returns the cursors of the threads
+// that have more.
+func limitReplies(rows []database.GetCommentRepliesRow, perThread int) ([]database.GetCommentRepliesRow, map[uuid.UUID]string) {
+	counts := make(map[uuid.UUID]int)
+	last := make(map[uuid.UUID]database.GetCommentRepliesRow)
+	cursors := make(map[uuid.UUID]string)
+	kept := make([]database.GetCommentRepliesRow, 0, len(rows))
+	for _, row := range rows {
+		root := row.RootCommentID.UUID
+		counts[root]++
+		if counts[root] > perThread {
+			cursors[root] = encodeCursor(last[root].CreatedAt, last[root].ID)
+			continue
+		}
+		last[root] = row
+		kept = append(kept, row)
+	}
+	return kept, cursors
+}
+
+// HandleGetCommentReplies pages through the replies of a thread past the
+// ones listed with it. Replies come oldest first and flat, each with its
+// parent_comment_id.
+func (h *PostHandler) HandleGetCommentReplies(w http.ResponseWriter, r *http.Request) {
+	commentId, err := uuid.Parse(r.PathValue("comment_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	viewerId := viewerIdFromContext(r)
+	comment, err := h.DB.GetComment(r.Context(), commentId)
+	if err != nil || comment.ParentCommentID.Valid {
+		respondWithError(w, 404, "no comment found", err)
+		return
+	}
+	if _, ok := h.viewablePost(w, r, viewerId, comment.PostID); !ok {
+		return
+	}
+	blocked, err := h.VisibilityService.Blocks.IsBlocked(r.Context(), viewerId, comment.UserID.UUID)
+	if err != nil {
+		respondWithError(w, 500, "failed to check access", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, 404, "no comment found", services.ErrBlocked)
+		return
+	}
+	rows, err := h.DB.GetCommentReplies(r.Context(), database.GetCommentRepliesParams{
+		ViewerID:        viewerId,
+		RootIds:         []uuid.UUID{comment.ID},
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PerThread:       page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get replies", err)
+		return
+	}
+	var resp struct {
+		Replies    []Comment `json:"replies"`
+		NextCursor *string   `json:"next_cursor"`
+	}
+	rows, resp.NextCursor = paginate(rows, page, func(c database.GetCommentRepliesRow) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+	resp.Replies = make([]Comment, 0, len(rows))
+	for _, row := range rows {
+		resp.Replies = append(resp.Replies, commentFromRow(database.GetPostCommentsRow(row)))
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *PostHandler) HandlerLikeComment(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		CommentId uuid.UUID `json:"comment_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "wrong request", err)
+		return
+	}
+	comment, ok := h.viewableComment(w, r, uuid.NullUUID{UUID: userId, Valid: true}, req.CommentId)
+	if !ok {
+		return
+	}
+	unliked, err := h.DB.UnlikeComment(r.Context(), database.UnlikeCommentParams{
+		UserID:    userId,
+		CommentID: comment.ID,
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to unlike", err)
+		return
+	}
+	message := "Comment unliked"
+	if unliked == 0 {
+		_, err = h.DB.LikeComment(r.Context(), database.LikeCommentParams{
+			UserID:    userId,
+			CommentID: comment.ID,
+		})
+		if err != nil {
+			respondWithError(w, 500, "failed to like", err)
+			return
+		}
+		message = "Comment liked"
+	}
+	likeCount, err := h.DB.GetCommentLikeCount(r.Context(), comment.ID)
+	if err != nil {
+		respondWithError(w, 500, "failed to count likes", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, map[string]any{"success": true, "message": message, "likes_count": likeCount, "user_liked": unliked == 0})
+}
+
+// viewableComment loads a comment the viewer may see, which needs the post
+// to be visible to them and neither of them to have blocked the other. It
+// responds itself when it returns false.
+func (h *PostHandler) viewableComment(w http.ResponseWriter, r *http.Request, viewerId uuid.NullUUID, commentId uuid.UUID) (database.PostsComment, bool) {
+	comment, err := h.DB.GetComment(r.Context(), commentId)
+	if err != nil || comment.DeletedAt.Valid {
+		respondWithError(w, 404, "no comment found", err)
+		return database.PostsComment{}, false
+	}
+	if _, ok := h.viewablePost(w, r, viewerId, comment.PostID); !ok {
+		return database.PostsComment{}, false
+	}
+	blocked, err := h.VisibilityService.Blocks.IsBlocked(r.Context(), viewerId, comment.UserID.UUID)
+	if err != nil {
+		respondWithError(w, 500, "failed to check access", err)
+		return database.PostsComment{}, false
+	}
+	if blocked {
+		respondWithError(w, 404, "no comment found", services.ErrBlocked)
+		return database.PostsComment{}, false
+	}
+	return comment, true
+}
+
+func respondWithCommentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrEmptyComment):
+		respondWithError(w, http.StatusBadRequest, "Comment can't be empty", err)
+	case errors.Is(err, services.ErrCommentNotFound):
+		respondWithError(w, 404, "no comment found", err)
+	case errors.Is(err, services.ErrCommentTooDeep):
+		respondWithError(w, http.StatusBadRequest, "Replies can't be nested any deeper", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "failed to comment", err)
+	}
+}
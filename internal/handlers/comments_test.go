@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+func TestCommentTree(t *testing.T) {
+	reply := func(parent Comment) Comment {
+		id := parent.ID
+		return Comment{ID: uuid.New(), ParentCommentID: &id, Replies: []Comment{}}
+	}
+	first := Comment{ID: uuid.New(), Replies: []Comment{}}
+	second := Comment{ID: uuid.New(), Replies: []Comment{}}
+	answer := reply(first)
+	nested := reply(answer)
+	other := reply(second)
+	// the parent of this one is missing, its author is blocked
+	orphan := reply(Comment{ID: uuid.New()})
+	orphanReply := reply(orphan)
+
+	tree := commentTree([]Comment{first, second}, []Comment{answer, other, nested, orphan, orphanReply})
+	if len(tree) != 2 {
+		t.Fatalf("got %d top level comments, want 2", len(tree))
+	}
+	if len(tree[0].Replies) != 1 || tree[0].Replies[0].ID != answer.ID {
+		t.Fatalf("first comment replies = %+v", tree[0].Replies)
+	}
+	if len(tree[0].Replies[0].Replies) != 1 || tree[0].Replies[0].Replies[0].ID != nested.ID {
+		t.Errorf("nested reply missing: %+v", tree[0].Replies[0].Replies)
+	}
+	if len(tree[1].Replies) != 1 || tree[1].Replies[0].ID != other.ID {
+		t.Errorf("second comment replies = %+v", tree[1].Replies)
+	}
+}
+
+func TestLimitReplies(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	busy, quiet := uuid.New(), uuid.New()
+	var rows []database.GetCommentRepliesRow
+	for i := range 4 {
+		for _, root := range []uuid.UUID{busy, quiet} {
+			if root == quiet && i > 1 {
+				continue
+			}
+			rows = append(rows, database.GetCommentRepliesRow{
+				ID:            uuid.New(),
+				RootCommentID: uuid.NullUUID{UUID: root, Valid: true},
+				CreatedAt:     now.Add(time.Duration(i) * time.Minute),
+			})
+		}
+	}
+
+	kept, cursors := limitReplies(rows, 2)
+	if len(kept) != 4 {
+		t.Fatalf("kept %d replies, want 2 of each thread", len(kept))
+	}
+	if _, ok := cursors[quiet]; ok {
+		t.Error("a thread without more replies got a cursor")
+	}
+	c, err := decodeCursor(cursors[busy])
+	if err != nil {
+		t.Fatalf("busy thread cursor: %v", err)
+	}
+	// the cursor is the last listed reply of the thread
+	if c.ID != rows[2].ID || !c.CreatedAt.Equal(rows[2].CreatedAt) {
+		t.Errorf("cursor = %+v, want the second reply of the thread", c)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"net/http"
+	"time"
+
+	"github.com/sssseraphim/fitterBy/internal/middleware"
+)
+
+// SessionModeHeader lets browser clients ask for cookie sessions instead of
+// tokens in the response body.
+const SessionModeHeader = "X-Session-Mode"
+
+// CookieConfig enables cookie sessions for the bundled UI. API clients that
+// don't send SessionModeHeader keep getting bearer tokens.
+type CookieConfig struct {
+	Enabled bool
+	// Secure should be set whenever the app is served over HTTPS.
+	Secure bool
+}
+
+func (h *AuthHandler) wantsCookies(r *http.Request) bool {
+	return h.Cookies.Enabled && r.Header.Get(SessionModeHeader) == "cookie"
+}
+
+// usesCookieSession reports whether the request was authenticated with the
+// access token cookie rather than a bearer token.
+func (h *AuthHandler) usesCookieSession(r *http.Request) bool {
+	if !h.Cookies.Enabled || r.Header.Get("Authorization") != "" {
+		return false
+	}
+	_, err := r.Cookie(middleware.AccessTokenCookie)
+	return err == nil
+}
+
+// setSessionCookies stores the tokens in HttpOnly cookies and returns a fresh
+// CSRF token, which is also set as a cookie readable by the page.
+func (h *AuthHandler) setSessionCookies(w http.ResponseWriter, accessToken, refreshToken string) string {
+	csrfToken := rand.Text()
+	refreshExpiry := h.TokenService.JWTConfig.RefreshTokenExpiry
+	http.SetCookie(w, h.cookie(middleware.AccessTokenCookie, accessToken, "/", h.TokenService.JWTConfig.AccessTokenExpiry, true))
+	http.SetCookie(w, h.cookie(middleware.RefreshTokenCookie, refreshToken, "/api/auth", refreshExpiry, true))
+	http.SetCookie(w, h.cookie(middleware.CSRFCookie, csrfToken, "/", refreshExpiry, false))
+	return csrfToken
+}
+
+func (h *AuthHandler) clearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.cookie(middleware.AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(w, h.cookie(middleware.RefreshTokenCookie, "", "/api/auth", -1, true))
+	http.SetCookie(w, h.cookie(middleware.CSRFCookie, "", "/", -1, false))
+}
+
+func (h *AuthHandler) cookie(name, value, path string, maxAge time.Duration, httpOnly bool) *http.Cookie {
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		HttpOnly: httpOnly,
+		Secure:   h.Cookies.Secure,
+		SameSite: http.SameSiteStrictMode,
+	}
+	if maxAge < 0 {
+		c.MaxAge = -1
+	} else {
+		c.MaxAge = int(maxAge.Seconds())
+	}
+	return c
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+func (h *AuthHandler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		respondWithError(w, 400, "token query parameter is required", nil)
+		return
+	}
+	err := h.EmailVerificationService.Verify(r.Context(), token)
+	if errors.Is(err, services.ErrInvalidVerificationToken) {
+		respondWithError(w, http.StatusBadRequest, "Verification link is invalid or expired", err)
+		return
+	}
+	if errors.Is(err, services.ErrEmailTaken) {
+		respondWithError(w, http.StatusConflict, "Email already exists", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to verify email", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Email verified"})
+}
+
+func (h *AuthHandler) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	user, err := h.DB.GetUser(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 404, "no user found", err)
+		return
+	}
+	if !checkMailRequests(w, r, h.LoginGuard, user.Email) {
+		return
+	}
+	err = h.EmailVerificationService.SendVerification(r.Context(), userId)
+	if errors.Is(err, services.ErrEmailAlreadyVerified) {
+		respondWithError(w, http.StatusConflict, "Email already verified", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to send verification email", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Verification email sent"})
+}
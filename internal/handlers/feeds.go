@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// HandleGetHomeFeed ranks recent posts of followed users and of the authors
+// of programs the user does. GET /api/posts/followed keeps the chronological
+// feed.
+func (h *PostHandler) HandleGetHomeFeed(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	page, err := feedPageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	posts, next, err := h.FeedService.Home(r.Context(), userId, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load feed", err)
+		return
+	}
+	h.respondWithFeed(w, r, uuid.NullUUID{UUID: userId, Valid: true}, posts, encodeFeedCursor(page, next))
+}
+
+// HandleGetExplore ranks recent public posts of users the viewer doesn't
+// follow yet. It works without logging in.
+func (h *PostHandler) HandleGetExplore(w http.ResponseWriter, r *http.Request) {
+	page, err := feedPageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	viewerId := viewerIdFromContext(r)
+	posts, next, err := h.FeedService.Explore(r.Context(), viewerId, page)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load feed", err)
+		return
+	}
+	h.respondWithFeed(w, r, viewerId, posts, encodeFeedCursor(page, next))
+}
+
+func (h *PostHandler) respondWithFeed(w http.ResponseWriter, r *http.Request, viewerId uuid.NullUUID, posts []services.FeedPost, next *string) {
+	resp := struct {
+		Posts      []Post  `json:"posts"`
+		NextCursor *string `json:"next_cursor"`
+	}{Posts: []Post{}, NextCursor: next}
+	for _, p := range posts {
+		resp.Posts = append(resp.Posts, Post{
+			ID:            p.ID,
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
+			EditedAt:      editedAt(p.EditedAt),
+			Content:       p.Content,
+			MediaUrls:     p.MediaUrls,
+			AuthorId:      p.AuthorID,
+			AuthorName:    p.AuthorName,
+			Visibility:    p.Visibility,
+			LikesCount:    int(p.LikeCount),
+			CommentsCount: int(p.CommentsCount),
+			Liked:         p.UserLiked,
+			RepostsCount:  int(p.RepostsCount),
+			WorkoutId:     optionalId(p.WorkoutID),
+			ProgramId:     optionalId(p.ProgramID),
+			RepostOf:      optionalId(p.RepostOf),
+		})
+	}
+	var err error
+	resp.Posts, err = embedPosts(r.Context(), h.DB, h.AttachmentService, viewerId, resp.Posts)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to load feed", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, resp)
+}
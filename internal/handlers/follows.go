@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type FollowUser struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	AvatarURL  string    `json:"avatar_url"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+type FollowList struct {
+	Count      int64        `json:"count"`
+	Users      []FollowUser `json:"users"`
+	NextCursor *string      `json:"next_cursor"`
+}
+
+func (h *UserHandler) HandlerFollow(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+
+	var request struct {
+		ID uuid.UUID `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		respondWithError(w, 400, "failed to decode user", err)
+		return
+	}
+	status, err := h.FollowService.Follow(r.Context(), userId, request.ID)
+	switch {
+	case errors.Is(err, services.ErrCannotFollowSelf):
+		respondWithError(w, http.StatusBadRequest, "You can't follow yourself", err)
+		return
+	case errors.Is(err, services.ErrUserNotFound):
+		respondWithError(w, 404, "no user found", err)
+		return
+	case errors.Is(err, services.ErrBlocked):
+		respondWithError(w, http.StatusForbidden, "You can't follow this user", err)
+		return
+	case err != nil:
+		respondWithError(w, 500, fmt.Sprintf("failed to follow: %v", err), err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]any{"user_id": request.ID, "status": status})
+}
+
+func (h *UserHandler) HandleUnfollow(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	followedId, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	err = h.FollowService.Unfollow(r.Context(), userId, followedId)
+	if errors.Is(err, services.ErrNotFollowing) {
+		respondWithError(w, 404, "You don't follow this user", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to unfollow", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Unfollowed"})
+}
+
+func (h *UserHandler) HandlerGetFollowedUsers(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	counts, err := h.DB.GetFollowCounts(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to count follows", err)
+		return
+	}
+	rows, err := h.DB.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, fmt.Sprintf("failed to get follows: %v", err), err)
+		return
+	}
+	rows, next := paginate(rows, page, func(row database.GetFollowingRow) (time.Time, uuid.UUID) {
+		return row.FollowedAt, row.ID
+	})
+	users := make([]FollowUser, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, FollowUser{ID: row.ID, Name: row.Name, AvatarURL: row.AvatarUrl, FollowedAt: row.FollowedAt})
+	}
+	respondWithJSON(w, 200, FollowList{Count: counts.FollowingCount, Users: users, NextCursor: next})
+}
+
+func (h *UserHandler) HandleGetFollowers(w http.ResponseWriter, r *http.Request) {
+	h.respondWithConnections(w, r, func(ownerId uuid.UUID, page page) ([]FollowUser, *string, error) {
+		rows, err := h.DB.GetFollowers(r.Context(), database.GetFollowersParams{
+			UserID:          ownerId,
+			CursorCreatedAt: page.CreatedAt,
+			CursorID:        page.ID,
+			PageSize:        page.limit(),
+		})
+		rows, next := paginate(rows, page, func(row database.GetFollowersRow) (time.Time, uuid.UUID) {
+			return row.FollowedAt, row.ID
+		})
+		users := make([]FollowUser, 0, len(rows))
+		for _, row := range rows {
+			users = append(users, FollowUser{ID: row.ID, Name: row.Name, AvatarURL: row.AvatarUrl, FollowedAt: row.FollowedAt})
+		}
+		return users, next, err
+	}, func(c database.GetFollowCountsRow) int64 { return c.FollowersCount })
+}
+
+func (h *UserHandler) HandleGetFollowing(w http.ResponseWriter, r *http.Request) {
+	h.respondWithConnections(w, r, func(ownerId uuid.UUID, page page) ([]FollowUser, *string, error) {
+		rows, err := h.DB.GetFollowing(r.Context(), database.GetFollowingParams{
+			UserID:          ownerId,
+			CursorCreatedAt: page.CreatedAt,
+			CursorID:        page.ID,
+			PageSize:        page.limit(),
+		})
+		rows, next := paginate(rows, page, func(row database.GetFollowingRow) (time.Time, uuid.UUID) {
+			return row.FollowedAt, row.ID
+		})
+		users := make([]FollowUser, 0, len(rows))
+		for _, row := range rows {
+			users = append(users, FollowUser{ID: row.ID, Name: row.Name, AvatarURL: row.AvatarUrl, FollowedAt: row.FollowedAt})
+		}
+		return users, next, err
+	}, func(c database.GetFollowCountsRow) int64 { return c.FollowingCount })
+}
+
+// respondWithConnections lists a page of followers or followed users of the
+// user in the path, if the caller is allowed to see them.
+func (h *UserHandler) respondWithConnections(w http.ResponseWriter, r *http.Request, list func(uuid.UUID, page) ([]FollowUser, *string, error), count func(database.GetFollowCountsRow) int64) {
+	userId := userIdFromContext(r)
+	ownerId, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	owner, err := h.DB.GetUser(r.Context(), ownerId)
+	if err != nil || owner.DeletionScheduledAt.Valid {
+		respondWithError(w, 404, "no user found", err)
+		return
+	}
+	blocked, err := h.BlockService.IsBlocked(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, ownerId)
+	if err != nil {
+		respondWithError(w, 500, "failed to check access", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, 404, "no user found", services.ErrBlocked)
+		return
+	}
+	allowed, err := h.FollowService.CanViewConnections(r.Context(), userId, ownerId)
+	if err != nil {
+		respondWithError(w, 500, "failed to check access", err)
+		return
+	}
+	if !allowed {
+		respondWithError(w, http.StatusForbidden, "This account is private", errors.New("not an accepted follower"))
+		return
+	}
+	counts, err := h.DB.GetFollowCounts(r.Context(), ownerId)
+	if err != nil {
+		respondWithError(w, 500, "failed to count follows", err)
+		return
+	}
+	users, next, err := list(ownerId, page)
+	if err != nil {
+		respondWithError(w, 500, "failed to get follows", err)
+		return
+	}
+	respondWithJSON(w, 200, FollowList{Count: count(counts), Users: users, NextCursor: next})
+}
+
+func (h *UserHandler) HandleGetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	requests, err := h.DB.GetPendingFollowRequests(r.Context(), database.GetPendingFollowRequestsParams{
+		FollowedID:      userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get follow requests", err)
+		return
+	}
+	var resp struct {
+		Requests   []FollowUser `json:"requests"`
+		NextCursor *string      `json:"next_cursor"`
+	}
+	requests, resp.NextCursor = paginate(requests, page, func(req database.GetPendingFollowRequestsRow) (time.Time, uuid.UUID) {
+		return req.RequestedAt, req.ID
+	})
+	resp.Requests = []FollowUser{}
+	for _, req := range requests {
+		resp.Requests = append(resp.Requests, FollowUser{
+			ID:         req.ID,
+			Name:       req.Name,
+			AvatarURL:  req.AvatarUrl,
+			FollowedAt: req.RequestedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *UserHandler) HandleApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideFollowRequest(w, r, true)
+}
+
+func (h *UserHandler) HandleRejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideFollowRequest(w, r, false)
+}
+
+func (h *UserHandler) decideFollowRequest(w http.ResponseWriter, r *http.Request, approve bool) {
+	userId := userIdFromContext(r)
+	followerId, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	message := "Follow request approved"
+	if approve {
+		err = h.FollowService.Approve(r.Context(), userId, followerId)
+	} else {
+		err = h.FollowService.Reject(r.Context(), userId, followerId)
+		message = "Follow request rejected"
+	}
+	if errors.Is(err, services.ErrFollowRequestNotFound) {
+		respondWithError(w, 404, "follow request not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to update follow request", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": message})
+}
@@ -0,0 +1,10 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+func (h *AuthHandler) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondWithJSON(w, 200, h.JWTConfig.KeySet.JWKS())
+}
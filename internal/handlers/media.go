@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type MediaHandler struct {
+	MediaService *services.MediaService
+}
+
+type Media struct {
+	ID           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// HandleUploadMedia stores the image sent in the "file" field of a
+// multipart form.
+func (h *MediaHandler) HandleUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	// leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, h.MediaService.MaxBytes+1<<20)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithMediaError(w, h.MediaService.MaxBytes, services.ErrMediaTooLarge)
+			return
+		}
+		respondWithError(w, http.StatusBadRequest, "Send the image in the file field of a multipart form", err)
+		return
+	}
+	defer file.Close()
+	media, err := h.MediaService.Upload(r.Context(), userId, file)
+	if err != nil {
+		respondWithMediaError(w, h.MediaService.MaxBytes, err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, Media{
+		ID:           media.ID,
+		URL:          h.MediaService.Storage.URL(media.StorageKey),
+		ThumbnailURL: h.MediaService.Storage.URL(media.ThumbnailKey),
+		ContentType:  media.ContentType,
+		Size:         media.SizeBytes,
+		Width:        int(media.Width),
+		Height:       int(media.Height),
+		CreatedAt:    media.CreatedAt,
+	})
+}
+
+func respondWithMediaError(w http.ResponseWriter, maxBytes int64, err error) {
+	switch {
+	case errors.Is(err, services.ErrMediaTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Files can be at most %d MB", maxBytes>>20), err)
+	case errors.Is(err, services.ErrImageTooLarge):
+		respondWithError(w, http.StatusRequestEntityTooLarge, "Image has too many pixels", err)
+	case errors.Is(err, services.ErrUnsupportedMedia):
+		respondWithError(w, http.StatusUnsupportedMediaType, "Only JPEG and PNG images can be uploaded", err)
+	case errors.Is(err, services.ErrMediaNotFound):
+		respondWithError(w, http.StatusBadRequest, "Media not found, attach images you uploaded", err)
+	case errors.Is(err, services.ErrTooManyMedia):
+		respondWithError(w, http.StatusBadRequest, "At most 10 images can be attached", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "failed to store media", err)
+	}
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+type NotificationHandler struct {
+	DB *database.Queries
+}
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	ActorID   uuid.UUID  `json:"actor_id"`
+	ActorName string     `json:"actor_name"`
+	PostID    *uuid.UUID `json:"post_id"`
+	CommentID *uuid.UUID `json:"comment_id"`
+	Read      bool       `json:"read"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (h *NotificationHandler) HandleGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	rows, err := h.DB.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get notifications", err)
+		return
+	}
+	unread, err := h.DB.CountUnreadNotifications(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get notifications", err)
+		return
+	}
+	var resp struct {
+		Notifications []Notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+		NextCursor    *string        `json:"next_cursor"`
+	}
+	rows, resp.NextCursor = paginate(rows, page, func(n database.GetNotificationsRow) (time.Time, uuid.UUID) {
+		return n.CreatedAt, n.ID
+	})
+	resp.UnreadCount = unread
+	resp.Notifications = []Notification{}
+	for _, row := range rows {
+		resp.Notifications = append(resp.Notifications, Notification{
+			ID:        row.ID,
+			Type:      row.Type,
+			ActorID:   row.ActorID,
+			ActorName: row.ActorName,
+			PostID:    optionalId(row.PostID),
+			CommentID: optionalId(row.CommentID),
+			Read:      row.ReadAt.Valid,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *NotificationHandler) HandleMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	if err := h.DB.MarkNotificationsRead(r.Context(), userId); err != nil {
+		respondWithError(w, 500, "failed to mark notifications as read", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Notifications marked as read"})
+}
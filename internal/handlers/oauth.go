@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/oauth"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// oauthStateCookie binds a provider sign in or link to the browser that
+// started it, so a callback URL opened by anyone else is rejected.
+const oauthStateCookie = "fitterby_oauth_state"
+
+type Identity struct {
+	Provider  string    `json:"provider"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (h *AuthHandler) HandleGetOAuthProviders(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, 200, map[string][]string{"providers": h.OAuthService.ProviderNames()})
+}
+
+// HandleOAuthLogin sends the browser to the provider. Pass
+// ?session_mode=cookie to get a cookie session from the callback.
+func (h *AuthHandler) HandleOAuthLogin(w http.ResponseWriter, r *http.Request) {
+	cookies := h.Cookies.Enabled && r.URL.Query().Get("session_mode") == "cookie"
+	authURL, state, err := h.OAuthService.Begin(r.Context(), r.PathValue("provider"), uuid.NullUUID{}, cookies)
+	if errors.Is(err, services.ErrUnknownProvider) {
+		respondWithError(w, 404, "Unknown provider", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to start login", err)
+		return
+	}
+	h.setOAuthStateCookie(w, state)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+func (h *AuthHandler) HandleOAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		respondWithError(w, http.StatusBadRequest, "Sign in was cancelled or failed: "+providerErr, errors.New(providerErr))
+		return
+	}
+	if query.Get("state") == "" || query.Get("code") == "" {
+		respondWithError(w, http.StatusBadRequest, "state and code are required", errors.New("missing callback parameters"))
+		return
+	}
+	stateCookie, err := r.Cookie(oauthStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(stateCookie.Value), []byte(query.Get("state"))) != 1 {
+		respondWithError(w, http.StatusBadRequest, "Sign in wasn't started in this browser, please try again", services.ErrInvalidOAuthState)
+		return
+	}
+	h.clearOAuthStateCookie(w)
+	result, err := h.OAuthService.Complete(r.Context(), provider, query.Get("state"), query.Get("code"))
+	switch {
+	case errors.Is(err, services.ErrUnknownProvider):
+		respondWithError(w, 404, "Unknown provider", err)
+		return
+	case errors.Is(err, services.ErrInvalidOAuthState):
+		respondWithError(w, http.StatusBadRequest, "Sign in expired, please try again", err)
+		return
+	case errors.Is(err, oauth.ErrExchangeFailed):
+		respondWithError(w, http.StatusUnauthorized, "Provider rejected the sign in", err)
+		return
+	case errors.Is(err, services.ErrIdentityInUse):
+		respondWithError(w, http.StatusConflict, "This account is already linked to another user", err)
+		return
+	case errors.Is(err, services.ErrProviderAlreadyLinked):
+		respondWithError(w, http.StatusConflict, "Another account of this provider is already linked", err)
+		return
+	case errors.Is(err, services.ErrOAuthEmailTaken):
+		respondWithError(w, http.StatusConflict, "An account with this email exists, log in and link the provider from your profile", err)
+		return
+	case errors.Is(err, services.ErrOAuthEmailUnverified):
+		respondWithError(w, http.StatusUnprocessableEntity, "The provider did not confirm your email", err)
+		return
+	case err != nil:
+		respondWithError(w, 500, "failed to sign in", err)
+		return
+	}
+	if result.Linked {
+		respondWithJSON(w, 200, map[string]string{"message": provider + " linked"})
+		return
+	}
+	user, err := h.DB.GetUser(r.Context(), result.UserID)
+	if err != nil {
+		respondWithError(w, 404, "no user found", err)
+		return
+	}
+	if result.Created {
+		h.respondWithTokens(w, r, http.StatusCreated, result.CookieSession, user.ID, user.Name, user.Email, user.Role)
+		return
+	}
+	h.completeLogin(w, r, result.CookieSession, user.ID, user.Name, user.Email, user.Role)
+}
+
+// HandleLinkIdentity returns the provider URL to open in the browser, since
+// the redirect itself can't carry the access token.
+func (h *AuthHandler) HandleLinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	authURL, state, err := h.OAuthService.Begin(r.Context(), r.PathValue("provider"), uuid.NullUUID{UUID: userId, Valid: true}, false)
+	if errors.Is(err, services.ErrUnknownProvider) {
+		respondWithError(w, 404, "Unknown provider", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to start linking", err)
+		return
+	}
+	h.setOAuthStateCookie(w, state)
+	respondWithJSON(w, 200, map[string]string{"authorization_url": authURL})
+}
+
+// setOAuthStateCookie remembers the state in the browser until the callback.
+// It has to be Lax, the provider sends the browser back cross site.
+func (h *AuthHandler) setOAuthStateCookie(w http.ResponseWriter, state string) {
+	c := h.cookie(oauthStateCookie, state, "/api/auth/oauth", h.OAuthService.StateExpiry, true)
+	c.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, c)
+}
+
+func (h *AuthHandler) clearOAuthStateCookie(w http.ResponseWriter) {
+	c := h.cookie(oauthStateCookie, "", "/api/auth/oauth", -1, true)
+	c.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, c)
+}
+
+func (h *AuthHandler) HandleGetIdentities(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	identities, err := h.DB.GetUserIdentities(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get linked accounts", err)
+		return
+	}
+	var resp struct {
+		Identities []Identity `json:"identities"`
+	}
+	for _, i := range identities {
+		resp.Identities = append(resp.Identities, Identity{
+			Provider:  i.Provider,
+			Email:     i.Email,
+			CreatedAt: i.CreatedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *AuthHandler) HandleUnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	err := h.OAuthService.Unlink(r.Context(), userId, r.PathValue("provider"))
+	if errors.Is(err, services.ErrIdentityNotFound) {
+		respondWithError(w, 404, "Provider not linked", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to unlink provider", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Provider unlinked"})
+}
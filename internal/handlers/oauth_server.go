@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sssseraphim/fitterBy/internal/auth"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type OAuthServerHandler struct {
+	DB      *database.Queries
+	Service *services.OAuthServerService
+}
+
+type OAuthApp struct {
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+	// ClientSecret is only returned once, when the app is registered.
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+func oauthAppFromDB(c database.OauthClient) OAuthApp {
+	return OAuthApp{
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectUris,
+		Public:       c.ClientSecretHash == "",
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+func (h *OAuthServerHandler) HandleRegisterApp(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Public       bool     `json:"public"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.Name == "" || len(req.RedirectURIs) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Name and redirect uris are required", errors.New("some fields are empty"))
+		return
+	}
+	client, secret, err := h.Service.RegisterClient(r.Context(), userId, req.Name, req.RedirectURIs, req.Public)
+	if errors.Is(err, services.ErrInvalidRedirectURI) {
+		respondWithError(w, http.StatusBadRequest, "Invalid redirect uri, use https, a custom scheme or http on localhost", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to register app", err)
+		return
+	}
+	resp := oauthAppFromDB(client)
+	resp.ClientSecret = secret
+	respondWithJSON(w, http.StatusCreated, resp)
+}
+
+func (h *OAuthServerHandler) HandleGetApps(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	clients, err := h.DB.GetOwnedOAuthClients(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get apps", err)
+		return
+	}
+	var resp struct {
+		Apps []OAuthApp `json:"apps"`
+	}
+	for _, c := range clients {
+		resp.Apps = append(resp.Apps, oauthAppFromDB(c))
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *OAuthServerHandler) HandleDeleteApp(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	err := h.Service.DeleteClient(r.Context(), userId, r.PathValue("client_id"))
+	if errors.Is(err, services.ErrOAuthClientNotFound) {
+		respondWithError(w, 404, "app not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to delete app", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "App deleted"})
+}
+
+type ScopeDescription struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+}
+
+func authorizationRequestFromQuery(r *http.Request) services.AuthorizationRequest {
+	q := r.URL.Query()
+	return services.AuthorizationRequest{
+		ResponseType:        q.Get("response_type"),
+		ClientID:            q.Get("client_id"),
+		RedirectURI:         q.Get("redirect_uri"),
+		Scope:               q.Get("scope"),
+		State:               q.Get("state"),
+		CodeChallenge:       q.Get("code_challenge"),
+		CodeChallengeMethod: q.Get("code_challenge_method"),
+	}
+}
+
+// respondWithAuthorizationError tells the consent screen to either show the
+// error or send the browser back to the app with it.
+func respondWithAuthorizationError(w http.ResponseWriter, req services.AuthorizationRequest, err error) {
+	switch {
+	case errors.Is(err, services.ErrOAuthClientNotFound):
+		respondWithError(w, http.StatusBadRequest, "Unknown app", err)
+	case errors.Is(err, services.ErrInvalidRedirectURI):
+		respondWithError(w, http.StatusBadRequest, "The app sent an unregistered redirect uri", err)
+	default:
+		var oauthErr *services.OAuthError
+		if !errors.As(err, &oauthErr) {
+			respondWithError(w, 500, "failed to authorize app", err)
+			return
+		}
+		respondWithJSON(w, http.StatusBadRequest, map[string]string{
+			"error":       oauthErr.Description,
+			"redirect_to": services.ErrorRedirect(req, err),
+		})
+	}
+}
+
+// HandleGetAuthorization returns what the consent screen shows.
+func (h *OAuthServerHandler) HandleGetAuthorization(w http.ResponseWriter, r *http.Request) {
+	req := authorizationRequestFromQuery(r)
+	client, scopes, err := h.Service.ValidateAuthorization(r.Context(), &req)
+	if err != nil {
+		respondWithAuthorizationError(w, req, err)
+		return
+	}
+	resp := struct {
+		ClientID    string             `json:"client_id"`
+		Name        string             `json:"name"`
+		RedirectURI string             `json:"redirect_uri"`
+		Scopes      []ScopeDescription `json:"scopes"`
+	}{
+		ClientID:    client.ClientID,
+		Name:        client.Name,
+		RedirectURI: req.RedirectURI,
+	}
+	for _, s := range scopes {
+		resp.Scopes = append(resp.Scopes, ScopeDescription{Scope: s, Description: auth.ScopeDescriptions[s]})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// HandleAuthorize records the user's decision on the consent screen and
+// returns where to send the browser.
+func (h *OAuthServerHandler) HandleAuthorize(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		services.AuthorizationRequest
+		Approve bool `json:"approve"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if !req.Approve {
+		if _, _, err := h.Service.ValidateAuthorization(r.Context(), &req.AuthorizationRequest); err != nil {
+			respondWithAuthorizationError(w, req.AuthorizationRequest, err)
+			return
+		}
+		respondWithJSON(w, 200, map[string]string{"redirect_to": services.ErrorRedirect(req.AuthorizationRequest, nil)})
+		return
+	}
+	redirect, err := h.Service.Authorize(r.Context(), userId, req.AuthorizationRequest)
+	if err != nil {
+		respondWithAuthorizationError(w, req.AuthorizationRequest, err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"redirect_to": redirect})
+}
+
+// HandleToken is the token endpoint of RFC 6749. Clients authenticate with
+// HTTP basic auth or client_id and client_secret in the form.
+func (h *OAuthServerHandler) HandleToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	if err := r.ParseForm(); err != nil {
+		respondWithOAuthError(w, services.OAuthError{Code: "invalid_request", Description: "malformed form"})
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	var tokens services.ClientTokens
+	var err error
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		tokens, err = h.Service.ExchangeCode(r.Context(), clientID, clientSecret, r.PostForm.Get("code"), r.PostForm.Get("redirect_uri"), r.PostForm.Get("code_verifier"))
+	case "refresh_token":
+		tokens, err = h.Service.Refresh(r.Context(), clientID, clientSecret, r.PostForm.Get("refresh_token"))
+	default:
+		respondWithOAuthError(w, services.OAuthError{Code: "unsupported_grant_type", Description: "use authorization_code or refresh_token"})
+		return
+	}
+	var oauthErr *services.OAuthError
+	if errors.As(err, &oauthErr) {
+		respondWithOAuthError(w, *oauthErr)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to issue tokens", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]any{
+		"access_token":  tokens.AccessToken,
+		"token_type":    "Bearer",
+		"expires_in":    tokens.ExpiresIn,
+		"refresh_token": tokens.RefreshToken,
+		"scope":         strings.Join(tokens.Scopes, " "),
+	})
+}
+
+func respondWithOAuthError(w http.ResponseWriter, err services.OAuthError) {
+	code := http.StatusBadRequest
+	if err.Code == "invalid_client" {
+		code = http.StatusUnauthorized
+	}
+	respondWithJSON(w, code, map[string]string{
+		"error":             err.Code,
+		"error_description": err.Description,
+	})
+}
+
+type AuthorizedApp struct {
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	Scopes       []string  `json:"scopes"`
+	AuthorizedAt time.Time `json:"authorized_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (h *OAuthServerHandler) HandleGetAuthorizedApps(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	grants, err := h.DB.GetUserOAuthGrants(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get authorized apps", err)
+		return
+	}
+	var resp struct {
+		Apps []AuthorizedApp `json:"apps"`
+	}
+	for _, g := range grants {
+		resp.Apps = append(resp.Apps, AuthorizedApp{
+			ClientID:     g.ClientID,
+			Name:         g.Name,
+			Scopes:       g.Scopes,
+			AuthorizedAt: g.CreatedAt,
+			UpdatedAt:    g.UpdatedAt,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *OAuthServerHandler) HandleRevokeAuthorizedApp(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	err := h.Service.RevokeGrant(r.Context(), userId, r.PathValue("client_id"))
+	if errors.Is(err, services.ErrOAuthGrantNotFound) {
+		respondWithError(w, 404, "app not authorized", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to revoke app", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "App access revoked"})
+}
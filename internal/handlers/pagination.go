@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidLimit  = errors.New("limit must be a positive number")
+)
+
+// page is the part of a list asked for with ?cursor=&limit=. Lists are
+// ordered by (created_at, id) and the cursor is the last item of the page
+// before, so pages stay stable while new items are added.
+type page struct {
+	CreatedAt sql.NullTime
+	ID        uuid.NullUUID
+	Size      int32
+}
+
+// cursor is encoded into the opaque next_cursor handed to clients.
+type cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+func pageFromRequest(r *http.Request) (page, error) {
+	size, err := pageSizeFromRequest(r)
+	if err != nil {
+		return page{}, err
+	}
+	p := page{Size: size}
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		c, err := decodeCursor(raw)
+		if err != nil {
+			return page{}, err
+		}
+		p.CreatedAt = sql.NullTime{Time: c.CreatedAt, Valid: true}
+		p.ID = uuid.NullUUID{UUID: c.ID, Valid: true}
+	}
+	return p, nil
+}
+
+func pageSizeFromRequest(r *http.Request) (int32, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageSize, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return 0, errInvalidLimit
+	}
+	return int32(min(n, maxPageSize)), nil
+}
+
+// limit is one more than the page size, the extra row tells whether another
+// page follows.
+func (p page) limit() int32 {
+	return p.Size + 1
+}
+
+// paginate cuts rows fetched with limit down to the page and returns the
+// cursor of the next page, nil on the last one.
+func paginate[T any](rows []T, p page, key func(T) (time.Time, uuid.UUID)) ([]T, *string) {
+	if len(rows) <= int(p.Size) {
+		return rows, nil
+	}
+	rows = rows[:p.Size]
+	next := encodeCursor(key(rows[len(rows)-1]))
+	return rows, &next
+}
+
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	data, _ := json.Marshal(cursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor{}, errInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.CreatedAt.IsZero() {
+		return cursor{}, errInvalidCursor
+	}
+	return c, nil
+}
+
+// rankedCursor is the cursor of ranked feeds. Ranked feeds are ordered by
+// (score, id) with scores computed as of the time of the first page, which
+// every cursor carries along.
+type rankedCursor struct {
+	At    time.Time `json:"at"`
+	Score float64   `json:"s"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func feedPageFromRequest(r *http.Request) (services.FeedPage, error) {
+	size, err := pageSizeFromRequest(r)
+	if err != nil {
+		return services.FeedPage{}, err
+	}
+	p := services.FeedPage{At: time.Now(), Size: int(size)}
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		data, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil {
+			return services.FeedPage{}, errInvalidCursor
+		}
+		var c rankedCursor
+		if err := json.Unmarshal(data, &c); err != nil || c.At.IsZero() || c.At.After(p.At) {
+			return services.FeedPage{}, errInvalidCursor
+		}
+		p.At = c.At
+		p.After = &services.FeedPosition{Score: c.Score, ID: c.ID}
+	}
+	return p, nil
+}
+
+// encodeFeedCursor returns the cursor of the page after next, nil on the
+// last page.
+func encodeFeedCursor(p services.FeedPage, next *services.FeedPosition) *string {
+	if next == nil {
+		return nil
+	}
+	data, _ := json.Marshal(rankedCursor{At: p.At, Score: next.Score, ID: next.ID})
+	raw := base64.RawURLEncoding.EncodeToString(data)
+	return &raw
+}
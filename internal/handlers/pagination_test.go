@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+func TestPageFromRequest(t *testing.T) {
+	createdAt := time.Date(2025, 3, 1, 12, 30, 0, 123456000, time.UTC)
+	id := uuid.New()
+	cursor := encodeCursor(createdAt, id)
+
+	p, err := pageFromRequest(httptest.NewRequest("GET", "/api/programs?limit=5&cursor="+cursor, nil))
+	if err != nil {
+		t.Fatalf("pageFromRequest: %v", err)
+	}
+	if p.Size != 5 || !p.CreatedAt.Valid || !p.CreatedAt.Time.Equal(createdAt) || p.ID.UUID != id {
+		t.Errorf("page = %+v, want size 5 after %v %v", p, createdAt, id)
+	}
+
+	p, err = pageFromRequest(httptest.NewRequest("GET", "/api/programs", nil))
+	if err != nil || p.Size != defaultPageSize || p.CreatedAt.Valid {
+		t.Errorf("default page = %+v, %v", p, err)
+	}
+	p, _ = pageFromRequest(httptest.NewRequest("GET", "/api/programs?limit=1000", nil))
+	if p.Size != maxPageSize {
+		t.Errorf("size = %d, want it capped at %d", p.Size, maxPageSize)
+	}
+	for _, query := range []string{"limit=0", "limit=abc", "cursor=not-a-cursor", "cursor=e30"} {
+		if _, err := pageFromRequest(httptest.NewRequest("GET", "/api/programs?"+query, nil)); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	type item struct {
+		createdAt time.Time
+		id        uuid.UUID
+	}
+	key := func(i item) (time.Time, uuid.UUID) { return i.createdAt, i.id }
+	now := time.Now().UTC()
+	items := []item{{now, uuid.New()}, {now.Add(-time.Minute), uuid.New()}, {now.Add(-2 * time.Minute), uuid.New()}}
+	p := page{Size: 2}
+
+	rows, next := paginate(items, p, key)
+	if len(rows) != 2 || next == nil {
+		t.Fatalf("got %d rows and cursor %v, want 2 rows and a cursor", len(rows), next)
+	}
+	c, err := decodeCursor(*next)
+	if err != nil || c.ID != items[1].id || !c.CreatedAt.Equal(items[1].createdAt) {
+		t.Errorf("cursor = %+v, %v, want the last item of the page", c, err)
+	}
+
+	rows, next = paginate(items[:2], p, key)
+	if len(rows) != 2 || next != nil {
+		t.Errorf("last page: got %d rows and cursor %v", len(rows), next)
+	}
+}
+
+func TestFeedPageFromRequest(t *testing.T) {
+	first, err := feedPageFromRequest(httptest.NewRequest("GET", "/api/posts/explore?limit=5", nil))
+	if err != nil || first.Size != 5 || first.After != nil || first.At.IsZero() {
+		t.Fatalf("first page = %+v, %v", first, err)
+	}
+	id := uuid.New()
+	cursor := encodeFeedCursor(first, &services.FeedPosition{Score: 0.1 + 0.2, ID: id})
+
+	p, err := feedPageFromRequest(httptest.NewRequest("GET", "/api/posts/explore?limit=5&cursor="+*cursor, nil))
+	if err != nil {
+		t.Fatalf("feedPageFromRequest: %v", err)
+	}
+	if !p.At.Equal(first.At) || p.After == nil || p.After.Score != 0.1+0.2 || p.After.ID != id {
+		t.Errorf("page = %+v, want the ranking time and position of the cursor", p)
+	}
+	if encodeFeedCursor(first, nil) != nil {
+		t.Error("last page has a cursor")
+	}
+
+	future := encodeFeedCursor(services.FeedPage{At: time.Now().Add(time.Hour)}, &services.FeedPosition{ID: id})
+	for _, query := range []string{"limit=0", "cursor=not-a-cursor", "cursor=e30", "cursor=" + *future} {
+		if _, err := feedPageFromRequest(httptest.NewRequest("GET", "/api/posts/explore?"+query, nil)); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+func (h *AuthHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.Email == "" {
+		respondWithError(w, http.StatusBadRequest, "Email is required", errors.New("empty email"))
+		return
+	}
+	if !checkMailRequests(w, r, h.LoginGuard, req.Email) {
+		return
+	}
+	// the mail is sent in the background and failures aren't reported, neither
+	// the response nor its timing may depend on the account existing
+	go func(ctx context.Context) {
+		if err := h.PasswordResetService.RequestReset(ctx, req.Email); err != nil {
+			log.Printf("failed to send password reset: %v", err)
+		}
+	}(context.WithoutCancel(r.Context()))
+	respondWithJSON(w, 200, map[string]string{"message": "If the email is registered, a reset link was sent"})
+}
+
+func (h *AuthHandler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		respondWithError(w, http.StatusBadRequest, "All fields are required", errors.New("some fields are empty"))
+		return
+	}
+	if len(req.Password) < 6 {
+		respondWithError(w, http.StatusBadRequest, "Weak password", errors.New("password should be at least 6 charachters long"))
+		return
+	}
+	hashedPassword, err := HashPassword(req.Password)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unhasheable password", errors.New("bad password"))
+		return
+	}
+	userId, err := h.PasswordResetService.ResetPassword(r.Context(), req.Token, hashedPassword)
+	if errors.Is(err, services.ErrInvalidResetToken) {
+		respondWithError(w, http.StatusBadRequest, "Reset token is invalid or expired", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to reset password", err)
+		return
+	}
+	if err := h.TokenService.RevokeUserTokens(r.Context(), userId.String()); err != nil {
+		respondWithError(w, 500, "failed to revoke sessions", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Password changed, please log in again"})
+}
+
+// HandleChangePassword checks the current password, signs out every other
+// device and issues fresh tokens for this one.
+func (h *AuthHandler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		respondWithError(w, http.StatusBadRequest, "All fields are required", errors.New("some fields are empty"))
+		return
+	}
+	if len(req.NewPassword) < 6 {
+		respondWithError(w, http.StatusBadRequest, "Weak password", errors.New("password should be at least 6 charachters long"))
+		return
+	}
+	user, ok := confirmPassword(w, r, h.DB, h.LoginGuard, userId, req.CurrentPassword)
+	if !ok {
+		return
+	}
+	hashedPassword, err := HashPassword(req.NewPassword)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Unhasheable password", errors.New("bad password"))
+		return
+	}
+	err = h.DB.UpdateUserPassword(r.Context(), database.UpdateUserPasswordParams{
+		ID:             userId,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to change password", err)
+		return
+	}
+	if err := h.TokenService.RevokeUserTokens(r.Context(), userId.String()); err != nil {
+		respondWithError(w, 500, "failed to revoke sessions", err)
+		return
+	}
+	h.respondWithTokens(w, r, http.StatusOK, h.usesCookieSession(r), userId, user.Name, user.Email, user.Role)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// PostWorkout is the summary of a workout shared in a post.
+type PostWorkout struct {
+	ID          uuid.UUID         `json:"id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	ProgramId   *uuid.UUID        `json:"program_id"`
+	ProgramName string            `json:"program_name,omitempty"`
+	DayName     string            `json:"day_name,omitempty"`
+	Lifts       []PostWorkoutLift `json:"lifts"`
+	TotalVolume int64             `json:"total_volume"`
+	PRs         int               `json:"prs"`
+}
+
+type PostWorkoutLift struct {
+	Exercise string `json:"exercise"`
+	Weight   int    `json:"weight"`
+	Sets     int    `json:"sets"`
+	Reps     int    `json:"reps"`
+	Volume   int64  `json:"volume"`
+	PR       bool   `json:"pr"`
+}
+
+// PostProgram is the card of a program shared in a post.
+type PostProgram struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	AuthorId         uuid.UUID `json:"author_id"`
+	AuthorName       string    `json:"author_name"`
+	MediaUrls        []string  `json:"media_urls"`
+	Visibility       string    `json:"visibility"`
+	Tags             []string  `json:"tags"`
+	DaysCount        int       `json:"days_count"`
+	SubscribersCount int       `json:"subscribers_count"`
+}
+
+// embedAttachments fills in the workouts and programs shared by the posts.
+// Programs the viewer may not see keep their program_id without a card.
+func embedAttachments(ctx context.Context, attachments *services.AttachmentService, viewerId uuid.NullUUID, posts []Post) error {
+	var workoutIds, programIds []uuid.UUID
+	for _, p := range posts {
+		if p.WorkoutId != nil {
+			workoutIds = append(workoutIds, *p.WorkoutId)
+		}
+		if p.ProgramId != nil {
+			programIds = append(programIds, *p.ProgramId)
+		}
+	}
+	if len(workoutIds) == 0 && len(programIds) == 0 {
+		return nil
+	}
+	loaded, err := attachments.Load(ctx, viewerId, workoutIds, programIds)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		if posts[i].WorkoutId != nil {
+			if w, ok := loaded.Workouts[*posts[i].WorkoutId]; ok {
+				posts[i].Workout = postWorkout(w)
+			}
+		}
+		if posts[i].ProgramId != nil {
+			if p, ok := loaded.Programs[*posts[i].ProgramId]; ok {
+				posts[i].Program = &PostProgram{
+					ID:               p.ID,
+					Name:             p.Name,
+					Description:      p.Description,
+					AuthorId:         p.UserID,
+					AuthorName:       p.AuthorName,
+					MediaUrls:        p.MediaUrls,
+					Visibility:       p.Visibility,
+					Tags:             p.Tags,
+					DaysCount:        int(p.DaysCount),
+					SubscribersCount: int(p.SubscribersCount),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func postWorkout(w services.WorkoutSummary) *PostWorkout {
+	workout := &PostWorkout{
+		ID:          w.ID,
+		CreatedAt:   w.CreatedAt,
+		ProgramId:   optionalId(w.ProgramID),
+		ProgramName: w.ProgramName,
+		DayName:     w.DayName,
+		Lifts:       []PostWorkoutLift{},
+		TotalVolume: w.TotalVolume,
+		PRs:         w.PRs,
+	}
+	for _, l := range w.Lifts {
+		workout.Lifts = append(workout.Lifts, PostWorkoutLift{
+			Exercise: l.Exercise,
+			Weight:   int(l.Weight),
+			Sets:     int(l.Sets),
+			Reps:     int(l.Reps),
+			Volume:   l.Volume,
+			PR:       l.PR,
+		})
+	}
+	return workout
+}
+
+func respondWithAttachmentError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWorkoutNotFound):
+		respondWithError(w, http.StatusBadRequest, "Workout not found, share workouts you logged", err)
+	case errors.Is(err, services.ErrProgramNotFound):
+		respondWithError(w, http.StatusBadRequest, "Program not found", err)
+	case errors.Is(err, services.ErrBothAttachments):
+		respondWithError(w, http.StatusBadRequest, "Share a workout or a program, not both", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "failed to check the shared workout or program", err)
+	}
+}
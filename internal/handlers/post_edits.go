@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
+	"github.com/sssseraphim/fitterBy/internal/middleware"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// Revision is an earlier version of a post or comment, as it was before the
+// edit made by EditorID at CreatedAt. Only the author and moderators get the
+// earlier content, everyone else just when it was edited.
+type Revision struct {
+	ID         uuid.UUID  `json:"id"`
+	EditorID   *uuid.UUID `json:"editor_id,omitempty"`
+	Content    *string    `json:"content,omitempty"`
+	MediaUrls  []string   `json:"media_urls,omitempty"`
+	Visibility string     `json:"visibility,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (h *PostHandler) HandleUpdatePost(w http.ResponseWriter, r *http.Request) {
+	postId, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	var req struct {
+		Content    *string      `json:"content"`
+		MediaIds   *[]uuid.UUID `json:"media_ids"`
+		Visibility *string      `json:"visibility"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "wrong request", err)
+		return
+	}
+	edit := services.PostEdit{
+		Content:    req.Content,
+		Visibility: req.Visibility,
+	}
+	if req.MediaIds != nil {
+		mediaUrls, err := h.MediaService.URLs(r.Context(), userIdFromContext(r), *req.MediaIds)
+		if err != nil {
+			respondWithMediaError(w, h.MediaService.MaxBytes, err)
+			return
+		}
+		edit.MediaUrls = &mediaUrls
+	}
+	post, err := h.PostService.EditPost(r.Context(), editorFromContext(r), postId, edit)
+	if err != nil {
+		respondWithEditError(w, err, "failed to update post")
+		return
+	}
+	respondWithJSON(w, 200, Post{
+		ID:         post.ID,
+		CreatedAt:  post.CreatedAt,
+		UpdatedAt:  post.UpdatedAt,
+		EditedAt:   editedAt(post.EditedAt),
+		Content:    post.Content,
+		MediaUrls:  post.MediaUrls,
+		AuthorId:   post.UserID,
+		Visibility: post.Visibility,
+		WorkoutId:  optionalId(post.WorkoutID),
+		ProgramId:  optionalId(post.ProgramID),
+		RepostOf:   optionalId(post.RepostOf),
+	})
+}
+
+func (h *PostHandler) HandleDeletePost(w http.ResponseWriter, r *http.Request) {
+	postId, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	if err := h.PostService.DeletePost(r.Context(), editorFromContext(r), postId); err != nil {
+		respondWithEditError(w, err, "failed to delete post")
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Post deleted"})
+}
+
+func (h *PostHandler) HandleGetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	postId, err := uuid.Parse(r.PathValue("post_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	post, ok := h.viewablePost(w, r, viewerIdFromContext(r), postId)
+	if !ok {
+		return
+	}
+	rows, err := h.DB.GetPostRevisions(r.Context(), postId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get revisions", err)
+		return
+	}
+	full := post.AuthorID.Valid && canSeeRevisions(r, post.AuthorID.UUID)
+	revisions := []Revision{}
+	for _, row := range rows {
+		revision := Revision{ID: row.ID, CreatedAt: row.CreatedAt}
+		if full {
+			revision.EditorID = optionalId(row.EditorID)
+			revision.Content = &row.Content
+			revision.MediaUrls = row.MediaUrls
+			revision.Visibility = row.Visibility
+		}
+		revisions = append(revisions, revision)
+	}
+	respondWithJSON(w, 200, map[string][]Revision{"revisions": revisions})
+}
+
+func (h *PostHandler) HandleUpdateComment(w http.ResponseWriter, r *http.Request) {
+	commentId, err := uuid.Parse(r.PathValue("comment_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "wrong request", err)
+		return
+	}
+	comment, err := h.PostService.EditComment(r.Context(), editorFromContext(r), commentId, req.Content)
+	if err != nil {
+		respondWithEditError(w, err, "failed to update comment")
+		return
+	}
+	respondWithJSON(w, 200, commentFromDB(comment))
+}
+
+func (h *PostHandler) HandleDeleteComment(w http.ResponseWriter, r *http.Request) {
+	commentId, err := uuid.Parse(r.PathValue("comment_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	if err := h.PostService.DeleteComment(r.Context(), editorFromContext(r), commentId); err != nil {
+		respondWithEditError(w, err, "failed to delete comment")
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Comment deleted"})
+}
+
+func (h *PostHandler) HandleGetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	commentId, err := uuid.Parse(r.PathValue("comment_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
+	comment, ok := h.viewableComment(w, r, viewerIdFromContext(r), commentId)
+	if !ok {
+		return
+	}
+	rows, err := h.DB.GetCommentRevisions(r.Context(), commentId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get revisions", err)
+		return
+	}
+	full := comment.UserID.Valid && canSeeRevisions(r, comment.UserID.UUID)
+	revisions := []Revision{}
+	for _, row := range rows {
+		revision := Revision{ID: row.ID, CreatedAt: row.CreatedAt}
+		if full {
+			revision.EditorID = optionalId(row.EditorID)
+			revision.Content = &row.Content
+		}
+		revisions = append(revisions, revision)
+	}
+	respondWithJSON(w, 200, map[string][]Revision{"revisions": revisions})
+}
+
+// canSeeRevisions reports whether the caller may read the earlier versions
+// of something authorId wrote. They can hold text from before a post was made
+// public or that a moderator removed.
+func canSeeRevisions(r *http.Request, authorId uuid.UUID) bool {
+	if !viewerIdFromContext(r).Valid {
+		return false
+	}
+	return editorFromContext(r).CanChange(authorId)
+}
+
+// editorFromContext returns the caller, who may change anything when they
+// are a moderator or an admin.
+func editorFromContext(r *http.Request) services.Editor {
+	return services.Editor{
+		ID:        userIdFromContext(r),
+		Moderator: middleware.HasRole(r.Context(), auth.RoleModerator, auth.RoleAdmin),
+	}
+}
+
+func respondWithEditError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrPostNotFound):
+		respondWithError(w, 404, "no post found", err)
+	case errors.Is(err, services.ErrCommentNotFound):
+		respondWithError(w, 404, "no comment found", err)
+	case errors.Is(err, services.ErrNotAuthor):
+		respondWithError(w, http.StatusForbidden, "Only the author or a moderator can do this", err)
+	case errors.Is(err, services.ErrInvalidVisibility):
+		respondWithError(w, http.StatusBadRequest, "Visibility must be public, followers or private", err)
+	case errors.Is(err, services.ErrEmptyComment):
+		respondWithError(w, http.StatusBadRequest, "Comment can't be empty", err)
+	case errors.Is(err, services.ErrEmptyQuote):
+		respondWithError(w, http.StatusBadRequest, "Quote posts can't be emptied, repost the post instead", err)
+	case errors.Is(err, services.ErrRepostAttachments):
+		respondWithError(w, http.StatusBadRequest, "Add a comment to share images in a repost", err)
+	default:
+		respondWithError(w, 500, message, err)
+	}
+}
+
+func editedAt(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func optionalId(id uuid.NullUUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	return &id.UUID
+}
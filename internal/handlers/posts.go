@@ -4,62 +4,122 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/sssseraphim/fitterBy/internal/database"
 	"github.com/sssseraphim/fitterBy/internal/middleware"
+	"github.com/sssseraphim/fitterBy/internal/services"
 )
 
 type PostHandler struct {
-	DB *database.Queries
+	DB                  *database.Queries
+	VisibilityService   *services.VisibilityService
+	PostService         *services.PostService
+	NotificationService *services.NotificationService
+	MediaService        *services.MediaService
+	FeedService         *services.FeedService
+	AttachmentService   *services.AttachmentService
 }
 
 type Post struct {
-	ID            uuid.UUID `json:"id"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	Content       string    `json:"content"`
-	AuthorId      uuid.UUID `json:"author_id"`
-	AuthorName    string    `json:"author_name"`
-	MediaUrls     []string  `json:"media_urls"`
-	Visibility    string    `json:"visibility"`
-	LikesCount    int       `json:"likes_count"`
-	Liked         bool      `json:"liked"`
-	CommentsCount int       `json:"comments_count"`
+	ID            uuid.UUID  `json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	EditedAt      *time.Time `json:"edited_at"`
+	Content       string     `json:"content"`
+	AuthorId      uuid.UUID  `json:"author_id"`
+	AuthorName    string     `json:"author_name"`
+	MediaUrls     []string   `json:"media_urls"`
+	Visibility    string     `json:"visibility"`
+	LikesCount    int        `json:"likes_count"`
+	Liked         bool       `json:"liked"`
+	CommentsCount int        `json:"comments_count"`
+	RepostsCount  int        `json:"reposts_count"`
+	// WorkoutId or ProgramId is set on posts sharing a workout or program,
+	// Workout or Program embeds its summary.
+	WorkoutId *uuid.UUID   `json:"workout_id"`
+	ProgramId *uuid.UUID   `json:"program_id"`
+	Workout   *PostWorkout `json:"workout,omitempty"`
+	Program   *PostProgram `json:"program,omitempty"`
+	// RepostOf is set on reposts, Original embeds the reposted post.
+	RepostOf *uuid.UUID `json:"repost_of"`
+	Original *Post      `json:"original,omitempty"`
 }
 
 func (h *PostHandler) HandleCreatePost(w http.ResponseWriter, r *http.Request) {
 	var request struct {
-		Content    string   `json:"content"`
-		MediaUrls  []string `json:"media_urls"`
-		Visibility string   `json:"visibility"`
+		Content    string        `json:"content"`
+		MediaIds   []uuid.UUID   `json:"media_ids"`
+		Visibility string        `json:"visibility"`
+		WorkoutId  uuid.NullUUID `json:"workout_id"`
+		ProgramId  uuid.NullUUID `json:"program_id"`
+		RepostOf   uuid.NullUUID `json:"repost_of"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		respondWithError(w, http.StatusBadRequest, "wrong request", err)
 		return
 	}
+	if request.Visibility == "" {
+		request.Visibility = services.VisibilityPublic
+	}
+	if !services.ValidVisibility(request.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "Visibility must be public, followers or private", services.ErrInvalidVisibility)
+		return
+	}
 	userId := uuid.MustParse(r.Context().Value(middleware.UserIDKey).(string))
-	post, err := h.DB.CreatePost(r.Context(), database.CreatePostParams{
+	mediaUrls, err := h.MediaService.URLs(r.Context(), userId, request.MediaIds)
+	if err != nil {
+		respondWithMediaError(w, h.MediaService.MaxBytes, err)
+		return
+	}
+	if err := h.AttachmentService.Check(r.Context(), userId, request.WorkoutId, request.ProgramId); err != nil {
+		respondWithAttachmentError(w, err)
+		return
+	}
+	params := database.CreatePostParams{
 		UserID:     userId,
 		Content:    request.Content,
-		MediaUrls:  request.MediaUrls,
+		MediaUrls:  mediaUrls,
 		Visibility: request.Visibility,
-	})
+		WorkoutID:  request.WorkoutId,
+		ProgramID:  request.ProgramId,
+	}
+	var post database.Post
+	if request.RepostOf.Valid {
+		original, ok := h.repostedPost(w, r, userId, request.RepostOf.UUID)
+		if !ok {
+			return
+		}
+		post, err = h.PostService.Repost(r.Context(), params, original)
+	} else {
+		post, err = h.PostService.CreatePost(r.Context(), params)
+	}
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to create post", err)
+		respondWithRepostError(w, err)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated, Post{
-		ID:        post.ID,
-		CreatedAt: post.CreatedAt,
-		UpdatedAt: post.UpdatedAt,
-		Content:   post.Content,
-		MediaUrls: post.MediaUrls,
-		AuthorId:  post.UserID,
-	})
+	resp := []Post{{
+		ID:         post.ID,
+		CreatedAt:  post.CreatedAt,
+		UpdatedAt:  post.UpdatedAt,
+		Content:    post.Content,
+		MediaUrls:  post.MediaUrls,
+		AuthorId:   post.UserID,
+		Visibility: post.Visibility,
+		WorkoutId:  optionalId(post.WorkoutID),
+		ProgramId:  optionalId(post.ProgramID),
+		RepostOf:   optionalId(post.RepostOf),
+	}}
+	resp, err = embedPosts(r.Context(), h.DB, h.AttachmentService, uuid.NullUUID{UUID: userId, Valid: true}, resp)
+	if err != nil || len(resp) == 0 {
+		respondWithError(w, http.StatusInternalServerError, "failed to load the shared post, workout or program", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, resp[0])
 
 }
 func (h *PostHandler) HandleGetPost(w http.ResponseWriter, r *http.Request) {
@@ -75,14 +135,21 @@ func (h *PostHandler) HandleGetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.DB.GetPost(r.Context(), postId)
-	if err != nil {
-		respondWithError(w, 404, "no post found", err)
+	viewerId := viewerIdFromContext(r)
+	post, ok := h.viewablePost(w, r, viewerId, postId)
+	if !ok {
 		return
 	}
-	if post.Visibility != "public" {
-		respondWithError(w, http.StatusUnauthorized, "post is for followers only", errors.New("wrong post visibility"))
-		return
+	liked := false
+	if viewerId.Valid {
+		liked, err = h.DB.CheckUserLikedPost(r.Context(), database.CheckUserLikedPostParams{
+			UserID: viewerId.UUID,
+			PostID: postId,
+		})
+		if err != nil {
+			respondWithError(w, 500, "failed to get post", err)
+			return
+		}
 	}
 	author, err := h.DB.GetUser(r.Context(), post.AuthorID.UUID)
 	if err != nil {
@@ -90,9 +157,11 @@ func (h *PostHandler) HandleGetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondWithJSON(w, 200, Post{
+	resp := []Post{{
 		ID:            post.ID,
 		CreatedAt:     post.CreatedAt,
+		UpdatedAt:     post.UpdatedAt,
+		EditedAt:      editedAt(post.EditedAt),
 		Content:       post.Content,
 		MediaUrls:     post.MediaUrls,
 		AuthorId:      post.AuthorID.UUID,
@@ -100,25 +169,56 @@ func (h *PostHandler) HandleGetPost(w http.ResponseWriter, r *http.Request) {
 		Visibility:    post.Visibility,
 		LikesCount:    int(post.LikeCount),
 		CommentsCount: int(post.CommentsCount),
-	})
+		RepostsCount:  int(post.RepostsCount),
+		Liked:         liked,
+		WorkoutId:     optionalId(post.WorkoutID),
+		ProgramId:     optionalId(post.ProgramID),
+		RepostOf:      optionalId(post.RepostOf),
+	}}
+	resp, err = embedPosts(r.Context(), h.DB, h.AttachmentService, viewerId, resp)
+	if err != nil {
+		respondWithError(w, 500, "failed to get post", err)
+		return
+	}
+	if len(resp) == 0 {
+		respondWithError(w, 404, "no post found", errors.New("reposted post not visible to the caller"))
+		return
+	}
+	respondWithJSON(w, 200, resp[0])
 }
 
 func (h *PostHandler) HandleGetFollowedPosts(w http.ResponseWriter, r *http.Request) {
 	userId := userIdFromContext(r)
 
-	posts, err := h.DB.GetFollowedPosts(r.Context(), userId)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	posts, err := h.DB.GetFollowedPosts(r.Context(), database.GetFollowedPostsParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
 	if err != nil {
 		respondWithError(w, 404, fmt.Sprintf("failed to show posts: %v", err), err)
 		return
 	}
 	var resp struct {
-		Posts []Post `json:"posts"`
+		Posts      []Post  `json:"posts"`
+		NextCursor *string `json:"next_cursor"`
 	}
+	posts, resp.NextCursor = paginate(posts, page, func(p database.GetFollowedPostsRow) (time.Time, uuid.UUID) {
+		return p.CreatedAt, p.ID
+	})
 	for _, p := range posts {
 		fmt.Print(p)
 		resp.Posts = append(resp.Posts, Post{
 			ID:            p.ID,
 			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
+			EditedAt:      editedAt(p.EditedAt),
 			Content:       p.Content,
 			MediaUrls:     p.MediaUrls,
 			AuthorId:      p.AuthorID,
@@ -127,8 +227,17 @@ func (h *PostHandler) HandleGetFollowedPosts(w http.ResponseWriter, r *http.Requ
 			LikesCount:    int(p.LikeCount),
 			CommentsCount: int(p.CommentsCount),
 			Liked:         p.UserLiked,
+			RepostsCount:  int(p.RepostsCount),
+			WorkoutId:     optionalId(p.WorkoutID),
+			ProgramId:     optionalId(p.ProgramID),
+			RepostOf:      optionalId(p.RepostOf),
 		})
 	}
+	resp.Posts, err = embedPosts(r.Context(), h.DB, h.AttachmentService, uuid.NullUUID{UUID: userId, Valid: true}, resp.Posts)
+	if err != nil {
+		respondWithError(w, 500, "failed to show posts", err)
+		return
+	}
 	respondWithJSON(w, 200, resp)
 }
 
@@ -142,6 +251,9 @@ func (h *PostHandler) HandlerLikePost(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 400, "wrong request", err)
 		return
 	}
+	if _, ok := h.viewablePost(w, r, uuid.NullUUID{UUID: userId, Valid: true}, req.PostId); !ok {
+		return
+	}
 	checkResult, err := h.DB.CheckUserLikedPost(r.Context(), database.CheckUserLikedPostParams{
 		UserID: userId,
 		PostID: req.PostId})
@@ -179,26 +291,46 @@ func userIdFromContext(r *http.Request) uuid.UUID {
 	return uuid.MustParse(r.Context().Value(middleware.UserIDKey).(string))
 }
 
+// viewerIdFromContext returns the caller on routes behind OptionalAuth, it is
+// not valid for anonymous requests.
+func viewerIdFromContext(r *http.Request) uuid.NullUUID {
+	userId, ok := r.Context().Value(middleware.UserIDKey).(string)
+	if !ok {
+		return uuid.NullUUID{}
+	}
+	id, err := uuid.Parse(userId)
+	return uuid.NullUUID{UUID: id, Valid: err == nil}
+}
+
 func (h *PostHandler) HandlerComment(w http.ResponseWriter, r *http.Request) {
 	userId := userIdFromContext(r)
 	var req struct {
-		PostId  uuid.UUID `json:"post_id"`
-		Content string    `json:"content"`
+		PostId          uuid.UUID  `json:"post_id"`
+		ParentCommentId *uuid.UUID `json:"parent_comment_id"`
+		Content         string     `json:"content"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, 400, "wrong request", err)
 		return
 	}
-	err := h.DB.CommentOnPost(r.Context(), database.CommentOnPostParams{
-		UserID:  userId,
-		PostID:  req.PostId,
-		Content: req.Content,
-	})
+	post, ok := h.viewablePost(w, r, uuid.NullUUID{UUID: userId, Valid: true}, req.PostId)
+	if !ok {
+		return
+	}
+	var parentId uuid.NullUUID
+	if req.ParentCommentId != nil {
+		parentId = uuid.NullUUID{UUID: *req.ParentCommentId, Valid: true}
+	}
+	comment, err := h.PostService.Comment(r.Context(), userId, req.PostId, parentId, req.Content)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to comment", err)
+		respondWithCommentError(w, err)
 		return
 	}
-	respondWithJSON(w, http.StatusCreated, map[string]string{"success": "success"})
+	// the comment is saved either way, missed mentions don't fail the request
+	if err := h.NotificationService.NotifyMentions(r.Context(), post, comment); err != nil {
+		log.Printf("failed to notify mentions: %v", err)
+	}
+	respondWithJSON(w, http.StatusCreated, map[string]any{"success": "success", "comment": commentFromDB(comment)})
 }
 
 func (h *PostHandler) HandlerGetComments(w http.ResponseWriter, r *http.Request) {
@@ -212,10 +344,80 @@ func (h *PostHandler) HandlerGetComments(w http.ResponseWriter, r *http.Request)
 		respondWithError(w, 400, "wrong post id format", err)
 		return
 	}
-	comments, err := h.DB.GetPostComments(r.Context(), postId)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	viewerId := viewerIdFromContext(r)
+	if _, ok := h.viewablePost(w, r, viewerId, postId); !ok {
+		return
+	}
+	// pages are made of top level comments, each with its first replies
+	rows, err := h.DB.GetPostComments(r.Context(), database.GetPostCommentsParams{
+		ViewerID:        viewerId,
+		PostID:          postId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get comment", err)
+		return
+	}
+	var resp struct {
+		Comments   []Comment `json:"comments"`
+		NextCursor *string   `json:"next_cursor"`
+	}
+	rows, resp.NextCursor = paginate(rows, page, func(c database.GetPostCommentsRow) (time.Time, uuid.UUID) {
+		return c.CreatedAt, c.ID
+	})
+	roots := make([]Comment, 0, len(rows))
+	rootIds := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		roots = append(roots, commentFromRow(row))
+		rootIds = append(rootIds, row.ID)
+	}
+	replyRows, err := h.DB.GetCommentReplies(r.Context(), database.GetCommentRepliesParams{
+		ViewerID:  viewerId,
+		RootIds:   rootIds,
+		PerThread: repliesPerThread + 1,
+	})
 	if err != nil {
 		respondWithError(w, 500, "failed to get comment", err)
 		return
 	}
-	respondWithJSON(w, 200, comments)
+	replyRows, cursors := limitReplies(replyRows, repliesPerThread)
+	for i := range roots {
+		if cursor, ok := cursors[roots[i].ID]; ok {
+			roots[i].RepliesCursor = &cursor
+		}
+	}
+	replies := make([]Comment, 0, len(replyRows))
+	for _, row := range replyRows {
+		replies = append(replies, commentFromRow(database.GetPostCommentsRow(row)))
+	}
+	resp.Comments = commentTree(roots, replies)
+	respondWithJSON(w, 200, resp)
+}
+
+// viewablePost loads a post the viewer may see. Posts they may not see are
+// reported as missing, so their existence doesn't leak. Comments and likes
+// use it for the parent post. It responds itself when it returns false.
+func (h *PostHandler) viewablePost(w http.ResponseWriter, r *http.Request, viewerId uuid.NullUUID, postId uuid.UUID) (database.GetPostRow, bool) {
+	post, err := h.DB.GetPost(r.Context(), postId)
+	if err != nil {
+		respondWithError(w, 404, "no post found", err)
+		return database.GetPostRow{}, false
+	}
+	allowed, err := h.VisibilityService.CanViewPost(r.Context(), viewerId, post.AuthorID.UUID, post.Visibility)
+	if err != nil {
+		respondWithError(w, 500, "failed to check post visibility", err)
+		return database.GetPostRow{}, false
+	}
+	if !allowed {
+		respondWithError(w, 404, "no post found", errors.New("post not visible to the caller"))
+		return database.GetPostRow{}, false
+	}
+	return post, true
 }
@@ -9,10 +9,14 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
 )
 
 type ProgramHandler struct {
-	DB *database.Queries
+	DB                *database.Queries
+	TagService        *services.TagService
+	MediaService      *services.MediaService
+	VisibilityService *services.VisibilityService
 }
 
 type Exercise struct {
@@ -52,6 +56,7 @@ type Program struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 	Visibility  string    `json:"visibility"`
+	Tags        []string  `json:"tags"`
 	Days        []Day     `json:"days"`
 }
 
@@ -67,19 +72,24 @@ type UserProgram struct {
 func (h *ProgramHandler) HandleCreateExercise(w http.ResponseWriter, r *http.Request) {
 	userId := userIdFromContext(r)
 	var req struct {
-		Name        string   `json:"name"`
-		Description string   `json:"description"`
-		MediaUrls   []string `json:"media_urls"`
+		Name        string      `json:"name"`
+		Description string      `json:"description"`
+		MediaIds    []uuid.UUID `json:"media_ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, 400, "failed to decode request", err)
 		return
 	}
+	mediaUrls, err := h.MediaService.URLs(r.Context(), userId, req.MediaIds)
+	if err != nil {
+		respondWithMediaError(w, h.MediaService.MaxBytes, err)
+		return
+	}
 	exercise, err := h.DB.CreateExercise(r.Context(), database.CreateExerciseParams{
 		UserID:      userId,
 		Name:        req.Name,
 		Description: req.Description,
-		MediaUrls:   req.MediaUrls,
+		MediaUrls:   mediaUrls,
 	})
 	if err != nil {
 		respondWithJSON(w, 500, "failed to create an exercise")
@@ -124,14 +134,27 @@ func (h *ProgramHandler) HandleGetExerciseById(w http.ResponseWriter, r *http.Re
 }
 
 func (h *ProgramHandler) HandleGetExercises(w http.ResponseWriter, r *http.Request) {
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
 	var response struct {
-		Exercises []Exercise `json:"exercises"`
+		Exercises  []Exercise `json:"exercises"`
+		NextCursor *string    `json:"next_cursor"`
 	}
-	exercises, err := h.DB.GetExercises(r.Context())
+	exercises, err := h.DB.GetExercises(r.Context(), database.GetExercisesParams{
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
 	if err != nil {
 		respondWithError(w, 500, "failed to get exercises", err)
 		return
 	}
+	exercises, response.NextCursor = paginate(exercises, page, func(e database.GetExercisesRow) (time.Time, uuid.UUID) {
+		return e.CreatedAt, e.ID
+	})
 	for _, exercise := range exercises {
 		response.Exercises = append(response.Exercises, Exercise{
 			ID:          exercise.ID,
@@ -148,22 +171,46 @@ func (h *ProgramHandler) HandleGetExercises(w http.ResponseWriter, r *http.Reque
 
 func (h *ProgramHandler) HandleCreateProgram(w http.ResponseWriter, r *http.Request) {
 	userId := userIdFromContext(r)
-	var req Program
+	var req struct {
+		Program
+		MediaIds []uuid.UUID `json:"media_ids"`
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("bad request: %v", err), err)
 		return
 	}
+	if req.Visibility == "" {
+		req.Visibility = services.VisibilityPublic
+	}
+	if !services.ValidVisibility(req.Visibility) {
+		respondWithError(w, http.StatusBadRequest, "Visibility must be public, followers or private", services.ErrInvalidVisibility)
+		return
+	}
+	tags, err := services.NormalizeTags(req.Tags)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Tags must be up to 10 words of letters, digits or underscores", err)
+		return
+	}
+	mediaUrls, err := h.MediaService.URLs(r.Context(), userId, req.MediaIds)
+	if err != nil {
+		respondWithMediaError(w, h.MediaService.MaxBytes, err)
+		return
+	}
 	program, err := h.DB.CreateProgram(r.Context(), database.CreateProgramParams{
 		Name:        req.Name,
 		UserID:      userId,
 		Description: req.Description,
-		MediaUrls:   req.MediaUrls,
+		MediaUrls:   mediaUrls,
 		Visibility:  req.Visibility,
 	})
 	if err != nil {
 		respondWithError(w, 500, "failed to create program", err)
 		return
 	}
+	if err := h.TagService.TagProgram(r.Context(), program.ID, tags); err != nil {
+		respondWithError(w, 500, "failed to create program", err)
+		return
+	}
 	for _, day := range req.Days {
 		d, err := h.DB.CreateProgramDay(r.Context(), database.CreateProgramDayParams{
 			ProgramID:   program.ID,
@@ -194,20 +241,34 @@ func (h *ProgramHandler) HandleCreateProgram(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *ProgramHandler) HandleGetPrograms(w http.ResponseWriter, r *http.Request) {
-	programs, err := h.DB.GetPrograms(r.Context())
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	programs, err := h.DB.GetPrograms(r.Context(), database.GetProgramsParams{
+		ViewerID:        viewerIdFromContext(r),
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
 	if err != nil {
 		respondWithError(w, 500, "failed to get programs", err)
 		return
 	}
 	var resp struct {
-		Programs []Program `json:"programs"`
+		Programs   []Program `json:"programs"`
+		NextCursor *string   `json:"next_cursor"`
 	}
+	programs, resp.NextCursor = paginate(programs, page, func(p database.GetProgramsRow) (time.Time, uuid.UUID) {
+		return p.CreatedAt, p.ID
+	})
 	for _, p := range programs {
 		resp.Programs = append(resp.Programs, Program{
 			ID:          p.ID,
 			UserId:      p.UserID,
 			Name:        p.Name,
-			AuthorName:  p.AuthorName.String,
+			AuthorName:  p.AuthorName,
 			Description: p.Description,
 			MediaUrls:   p.MediaUrls,
 			CreatedAt:   p.CreatedAt,
@@ -229,12 +290,21 @@ func (h *ProgramHandler) HandleGetProgram(w http.ResponseWriter, r *http.Request
 		respondWithError(w, 400, "wrong program id", err)
 		return
 	}
-	fmt.Println(programId)
 	program, err := h.DB.GetProgram(r.Context(), programId)
 	if err != nil {
 		respondWithError(w, 404, "failed to find program", err)
 		return
 	}
+	// hidden programs look the same as missing ones
+	visible, err := h.VisibilityService.CanViewPost(r.Context(), viewerIdFromContext(r), program.UserID, program.Visibility)
+	if err != nil {
+		respondWithError(w, 500, "failed to check access", err)
+		return
+	}
+	if !visible {
+		respondWithError(w, 404, "failed to find program", services.ErrProgramNotFound)
+		return
+	}
 	resp := Program{
 		ID:          program.ID,
 		UserId:      program.UserID,
@@ -246,6 +316,11 @@ func (h *ProgramHandler) HandleGetProgram(w http.ResponseWriter, r *http.Request
 		UpdatedAt:   program.UpdatedAt,
 		Visibility:  program.Visibility,
 	}
+	resp.Tags, err = h.DB.GetProgramTags(r.Context(), programId)
+	if err != nil {
+		respondWithError(w, 500, "failed to find program tags", err)
+		return
+	}
 	days, err := h.DB.GetProgramDays(r.Context(), programId)
 	if err != nil {
 		respondWithError(w, 500, "failed to find program days", err)
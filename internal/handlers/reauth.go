@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// checkLoginAttempts responds with 429 and reports false when the account or
+// IP failed too often lately. Callers defer release until the failure or
+// success of the attempt is recorded.
+func checkLoginAttempts(w http.ResponseWriter, r *http.Request, guard *services.LoginGuard, email, ip string) (release func(), ok bool) {
+	release, wait, err := guard.Begin(r.Context(), email, ip)
+	if errors.Is(err, services.ErrLoginThrottled) || errors.Is(err, services.ErrLoginLocked) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondWithError(w, http.StatusTooManyRequests, "Too many failed login attempts, try again later", err)
+		return release, false
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to check login attempts", err)
+		return release, false
+	}
+	return release, true
+}
+
+// checkMailRequests responds with 429 and reports false when the address or
+// IP asked for too many emails lately.
+func checkMailRequests(w http.ResponseWriter, r *http.Request, guard *services.LoginGuard, email string) bool {
+	wait, err := guard.AllowMail(r.Context(), email, clientInfoFromRequest(r).IPAddress)
+	if errors.Is(err, services.ErrLoginThrottled) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		respondWithError(w, http.StatusTooManyRequests, "Too many emails requested, try again later", err)
+		return false
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to check email requests", err)
+		return false
+	}
+	return true
+}
+
+// confirmPassword checks the password of the signed in user before a
+// sensitive change, so a stolen session alone can't make it. Wrong passwords
+// count against the same limits as failed logins. It responds itself when it
+// returns false.
+func confirmPassword(w http.ResponseWriter, r *http.Request, db *database.Queries, guard *services.LoginGuard, userId uuid.UUID, password string) (database.GetUserRow, bool) {
+	if password == "" {
+		respondWithError(w, http.StatusBadRequest, "Current password is required", errors.New("missing password"))
+		return database.GetUserRow{}, false
+	}
+	user, err := db.GetUser(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 404, "no user found", err)
+		return database.GetUserRow{}, false
+	}
+	ip := clientInfoFromRequest(r).IPAddress
+	release, ok := checkLoginAttempts(w, r, guard, user.Email, ip)
+	defer release()
+	if !ok {
+		return database.GetUserRow{}, false
+	}
+	login, err := db.GetUserLogin(r.Context(), user.Email)
+	if err != nil {
+		respondWithError(w, 500, "failed to get user", err)
+		return database.GetUserRow{}, false
+	}
+	valid, err := CheckPasswordHash(password, login.HashedPassword)
+	if err != nil || !valid {
+		if err := guard.RecordFailure(r.Context(), user.Email, ip, uuid.NullUUID{UUID: userId, Valid: true}); err != nil {
+			log.Printf("failed to record failed password check: %v", err)
+		}
+		respondWithError(w, http.StatusUnprocessableEntity, "Wrong password", errors.New("invalid credentials"))
+		return database.GetUserRow{}, false
+	}
+	return user, true
+}
+
+// confirmTwoFactorCode is confirmPassword for a two-factor or recovery code,
+// which accounts created through a provider can use as they have no password
+// they know.
+func confirmTwoFactorCode(w http.ResponseWriter, r *http.Request, db *database.Queries, guard *services.LoginGuard, twoFactor *services.TwoFactorService, userId uuid.UUID, code string) (database.GetUserRow, bool) {
+	user, err := db.GetUser(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 404, "no user found", err)
+		return database.GetUserRow{}, false
+	}
+	ip := clientInfoFromRequest(r).IPAddress
+	release, ok := checkLoginAttempts(w, r, guard, user.Email, ip)
+	defer release()
+	if !ok {
+		return database.GetUserRow{}, false
+	}
+	err = twoFactor.VerifyCode(r.Context(), userId, code)
+	switch {
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		respondWithError(w, http.StatusBadRequest, "Two-factor authentication is not enabled", err)
+		return database.GetUserRow{}, false
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		if err := guard.RecordFailure(r.Context(), user.Email, ip, uuid.NullUUID{UUID: userId, Valid: true}); err != nil {
+			log.Printf("failed to record failed code check: %v", err)
+		}
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid code", err)
+		return database.GetUserRow{}, false
+	case err != nil:
+		respondWithError(w, 500, "failed to check code", err)
+		return database.GetUserRow{}, false
+	}
+	return user, true
+}
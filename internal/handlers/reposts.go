@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+// embedPosts fills in the reposted posts and the shared workouts and
+// programs of posts. Reposts are only shown with the post they repost to
+// viewers who may see it: plain reposts of other posts are left out, quote
+// posts keep their repost_of without the original.
+func embedPosts(ctx context.Context, db *database.Queries, attachments *services.AttachmentService, viewerId uuid.NullUUID, posts []Post) ([]Post, error) {
+	var ids []uuid.UUID
+	for _, p := range posts {
+		if p.RepostOf != nil {
+			ids = append(ids, *p.RepostOf)
+		}
+	}
+	var rows []database.GetVisiblePostsRow
+	if len(ids) > 0 {
+		var err error
+		rows, err = db.GetVisiblePosts(ctx, database.GetVisiblePostsParams{
+			ViewerID: viewerId,
+			Ids:      ids,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	visible := make(map[uuid.UUID]bool, len(rows))
+	for _, row := range rows {
+		visible[row.ID] = true
+	}
+
+	kept := make([]Post, 0, len(posts)+len(rows))
+	for _, p := range posts {
+		if p.RepostOf != nil && p.Content == "" && !visible[*p.RepostOf] {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	n := len(kept)
+	// the originals go after the posts so their workouts and programs are
+	// loaded together
+	for _, row := range rows {
+		kept = append(kept, Post{
+			ID:            row.ID,
+			CreatedAt:     row.CreatedAt,
+			UpdatedAt:     row.UpdatedAt,
+			EditedAt:      editedAt(row.EditedAt),
+			Content:       row.Content,
+			MediaUrls:     row.MediaUrls,
+			AuthorId:      row.AuthorID,
+			AuthorName:    row.AuthorName,
+			Visibility:    row.Visibility,
+			LikesCount:    int(row.LikeCount),
+			Liked:         row.UserLiked,
+			CommentsCount: int(row.CommentsCount),
+			RepostsCount:  int(row.RepostsCount),
+			WorkoutId:     optionalId(row.WorkoutID),
+			ProgramId:     optionalId(row.ProgramID),
+			RepostOf:      optionalId(row.RepostOf),
+		})
+	}
+	if err := embedAttachments(ctx, attachments, viewerId, kept); err != nil {
+		return nil, err
+	}
+	posts, originals := kept[:n], kept[n:]
+	byId := make(map[uuid.UUID]*Post, len(originals))
+	for i := range originals {
+		byId[originals[i].ID] = &originals[i]
+	}
+	for i := range posts {
+		if posts[i].RepostOf != nil {
+			posts[i].Original = byId[*posts[i].RepostOf]
+		}
+	}
+	return posts, nil
+}
+
+// repostedPost loads the post to repost, the post a plain repost reposts in
+// place of the plain repost.
+func (h *PostHandler) repostedPost(w http.ResponseWriter, r *http.Request, userId, postId uuid.UUID) (database.GetPostRow, bool) {
+	viewerId := uuid.NullUUID{UUID: userId, Valid: true}
+	original, ok := h.viewablePost(w, r, viewerId, postId)
+	if ok && services.IsPlainRepost(original.Content, original.RepostOf) {
+		return h.viewablePost(w, r, viewerId, original.RepostOf.UUID)
+	}
+	return original, ok
+}
+
+func respondWithRepostError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrRepostPrivate):
+		respondWithError(w, http.StatusForbidden, "Private posts can't be reshared", err)
+	case errors.Is(err, services.ErrAlreadyReposted):
+		respondWithError(w, http.StatusConflict, "You already reposted this post", err)
+	case errors.Is(err, services.ErrRepostAttachments):
+		respondWithError(w, http.StatusBadRequest, "Add a comment to share images, a workout or a program in a repost", err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, "failed to create post", err)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+}
+
+func (h *AuthHandler) HandleGetSessions(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	sessions, err := h.DB.GetUserSessions(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get sessions", err)
+		return
+	}
+	var resp struct {
+		Sessions []Session `json:"sessions"`
+	}
+	for _, s := range sessions {
+		resp.Sessions = append(resp.Sessions, Session{
+			ID:         s.FamilyID,
+			CreatedAt:  s.SessionCreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IpAddress,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *AuthHandler) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	sessionIdString := r.PathValue("session_id")
+	if sessionIdString == "" {
+		respondWithError(w, 400, "session id required", errors.New("no id"))
+		return
+	}
+	sessionId, err := uuid.Parse(sessionIdString)
+	if err != nil {
+		respondWithError(w, 400, "wrong session id", err)
+		return
+	}
+	err = h.TokenService.RevokeSession(r.Context(), userId.String(), sessionId)
+	if errors.Is(err, services.ErrSessionNotFound) {
+		respondWithError(w, 404, "session not found", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to revoke session", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]string{"message": "Session revoked"})
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type TagHandler struct {
+	DB                *database.Queries
+	TagService        *services.TagService
+	AttachmentService *services.AttachmentService
+}
+
+type TrendingTag struct {
+	Name    string `json:"name"`
+	Uses    int    `json:"uses"`
+	Authors int    `json:"authors"`
+}
+
+func (h *TagHandler) HandleGetTagPosts(w http.ResponseWriter, r *http.Request) {
+	tag, ok := tagFromPath(w, r)
+	if !ok {
+		return
+	}
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	posts, err := h.DB.GetTagPosts(r.Context(), database.GetTagPostsParams{
+		ViewerID:        viewerIdFromContext(r),
+		Tag:             tag,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get posts", err)
+		return
+	}
+	resp := struct {
+		Tag        string  `json:"tag"`
+		Posts      []Post  `json:"posts"`
+		NextCursor *string `json:"next_cursor"`
+	}{Tag: tag, Posts: []Post{}}
+	posts, resp.NextCursor = paginate(posts, page, func(p database.GetTagPostsRow) (time.Time, uuid.UUID) {
+		return p.CreatedAt, p.ID
+	})
+	for _, p := range posts {
+		resp.Posts = append(resp.Posts, Post{
+			ID:            p.ID,
+			CreatedAt:     p.CreatedAt,
+			UpdatedAt:     p.UpdatedAt,
+			EditedAt:      editedAt(p.EditedAt),
+			Content:       p.Content,
+			MediaUrls:     p.MediaUrls,
+			AuthorId:      p.AuthorID,
+			AuthorName:    p.AuthorName,
+			Visibility:    p.Visibility,
+			LikesCount:    int(p.LikeCount),
+			CommentsCount: int(p.CommentsCount),
+			Liked:         p.UserLiked,
+			RepostsCount:  int(p.RepostsCount),
+			WorkoutId:     optionalId(p.WorkoutID),
+			ProgramId:     optionalId(p.ProgramID),
+			RepostOf:      optionalId(p.RepostOf),
+		})
+	}
+	resp.Posts, err = embedPosts(r.Context(), h.DB, h.AttachmentService, viewerIdFromContext(r), resp.Posts)
+	if err != nil {
+		respondWithError(w, 500, "failed to get posts", err)
+		return
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func (h *TagHandler) HandleGetTagPrograms(w http.ResponseWriter, r *http.Request) {
+	tag, ok := tagFromPath(w, r)
+	if !ok {
+		return
+	}
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	programs, err := h.DB.GetTagPrograms(r.Context(), database.GetTagProgramsParams{
+		Tag:             tag,
+		ViewerID:        viewerIdFromContext(r),
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
+	if err != nil {
+		respondWithError(w, 500, "failed to get programs", err)
+		return
+	}
+	resp := struct {
+		Tag        string    `json:"tag"`
+		Programs   []Program `json:"programs"`
+		NextCursor *string   `json:"next_cursor"`
+	}{Tag: tag, Programs: []Program{}}
+	programs, resp.NextCursor = paginate(programs, page, func(p database.GetTagProgramsRow) (time.Time, uuid.UUID) {
+		return p.CreatedAt, p.ID
+	})
+	for _, p := range programs {
+		resp.Programs = append(resp.Programs, Program{
+			ID:          p.ID,
+			UserId:      p.UserID,
+			Name:        p.Name,
+			AuthorName:  p.AuthorName,
+			Description: p.Description,
+			MediaUrls:   p.MediaUrls,
+			CreatedAt:   p.CreatedAt,
+			UpdatedAt:   p.UpdatedAt,
+			Visibility:  p.Visibility,
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+// HandleGetTrendingTags lists the tags trending over the last `hours`, 24 by
+// default.
+func (h *TagHandler) HandleGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window := services.DefaultTrendingWindow
+	if hours := r.URL.Query().Get("hours"); hours != "" {
+		n, err := strconv.Atoi(hours)
+		if err != nil || n < 1 || time.Duration(n)*time.Hour > services.MaxTrendingWindow {
+			respondWithError(w, http.StatusBadRequest, "hours must be between 1 and 720", err)
+			return
+		}
+		window = time.Duration(n) * time.Hour
+	}
+	rows, err := h.TagService.Trending(r.Context(), viewerIdFromContext(r), window)
+	if err != nil {
+		respondWithError(w, 500, "failed to get trending tags", err)
+		return
+	}
+	resp := struct {
+		Hours int           `json:"hours"`
+		Tags  []TrendingTag `json:"tags"`
+	}{Hours: int(window.Hours()), Tags: []TrendingTag{}}
+	for _, row := range rows {
+		resp.Tags = append(resp.Tags, TrendingTag{
+			Name:    row.Name,
+			Uses:    int(row.Uses),
+			Authors: int(row.Authors),
+		})
+	}
+	respondWithJSON(w, 200, resp)
+}
+
+func tagFromPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tag, err := services.NormalizeTag(r.PathValue("tag"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid tag", err)
+		return "", false
+	}
+	return tag, true
+}
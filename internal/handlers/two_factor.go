@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/middleware"
+	"github.com/sssseraphim/fitterBy/internal/services"
+)
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+func (h *AuthHandler) HandleEnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	email := r.Context().Value(middleware.EmailKey).(string)
+	secret, uri, err := h.TwoFactorService.Enroll(r.Context(), userId, email)
+	if errors.Is(err, services.ErrTwoFactorEnabled) {
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to enroll two-factor authentication", err)
+		return
+	}
+	respondWithJSON(w, http.StatusCreated, map[string]string{"secret": secret, "otpauth_uri": uri})
+}
+
+func (h *AuthHandler) HandleConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	recoveryCodes, err := h.TwoFactorService.Confirm(r.Context(), userId, req.Code)
+	switch {
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		respondWithError(w, http.StatusBadRequest, "Two-factor authentication is not enrolled", err)
+		return
+	case errors.Is(err, services.ErrTwoFactorEnabled):
+		respondWithError(w, http.StatusConflict, "Two-factor authentication is already enabled", err)
+		return
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid code", err)
+		return
+	case err != nil:
+		respondWithError(w, 500, "failed to confirm two-factor authentication", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]any{"enabled": true, "recovery_codes": recoveryCodes})
+}
+
+// HandleDisableTwoFactor needs the password as well as a code, a stolen
+// session together with a code read off the screen isn't enough.
+func (h *AuthHandler) HandleDisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	user, ok := confirmPassword(w, r, h.DB, h.LoginGuard, userId, req.Password)
+	if !ok {
+		return
+	}
+	err := h.TwoFactorService.Disable(r.Context(), userId, req.Code)
+	switch {
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		respondWithError(w, http.StatusBadRequest, "Two-factor authentication is not enabled", err)
+		return
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		ip := clientInfoFromRequest(r).IPAddress
+		if err := h.LoginGuard.RecordFailure(r.Context(), user.Email, ip, uuid.NullUUID{UUID: userId, Valid: true}); err != nil {
+			log.Printf("failed to record failed code check: %v", err)
+		}
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid code", err)
+		return
+	case err != nil:
+		respondWithError(w, 500, "failed to disable two-factor authentication", err)
+		return
+	}
+	respondWithJSON(w, 200, map[string]bool{"enabled": false})
+}
+
+func (h *AuthHandler) HandleLoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.MFAToken == "" || req.Code == "" {
+		respondWithError(w, http.StatusBadRequest, "All fields are required", errors.New("some fields are empty"))
+		return
+	}
+	userId, err := h.TwoFactorService.ChallengeUser(r.Context(), req.MFAToken)
+	if errors.Is(err, services.ErrInvalidMFAChallenge) {
+		respondWithError(w, http.StatusUnauthorized, "Login expired, please log in again", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, 500, "failed to check code", err)
+		return
+	}
+	user, err := h.DB.GetUser(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 404, "no user found", err)
+		return
+	}
+	// codes are guessed against the same limits as passwords, a fresh
+	// challenge doesn't give fresh attempts
+	ip := clientInfoFromRequest(r).IPAddress
+	release, ok := checkLoginAttempts(w, r, h.LoginGuard, user.Email, ip)
+	defer release()
+	if !ok {
+		return
+	}
+	_, err = h.TwoFactorService.CompleteChallenge(r.Context(), req.MFAToken, req.Code)
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAChallenge), errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		respondWithError(w, http.StatusUnauthorized, "Login expired, please log in again", err)
+		return
+	case errors.Is(err, services.ErrInvalidTwoFactorCode):
+		if err := h.LoginGuard.RecordFailure(r.Context(), user.Email, ip, uuid.NullUUID{UUID: user.ID, Valid: true}); err != nil {
+			log.Printf("failed to record failed login: %v", err)
+		}
+		respondWithError(w, http.StatusUnprocessableEntity, "Invalid code", err)
+		return
+	case err != nil:
+		respondWithError(w, 500, "failed to check code", err)
+		return
+	}
+	if h.respondWithTokens(w, r, http.StatusOK, h.wantsCookies(r), user.ID, user.Name, user.Email, user.Role) {
+		h.recordLoginSuccess(r, user.Email)
+	}
+}
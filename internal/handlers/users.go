@@ -8,22 +8,74 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
 	"github.com/sssseraphim/fitterBy/internal/database"
 	"github.com/sssseraphim/fitterBy/internal/middleware"
+	"github.com/sssseraphim/fitterBy/internal/services"
 )
 
 type UserHandler struct {
-	DB *database.Queries
+	DB                       *database.Queries
+	AccountService           *services.AccountService
+	ProfileService           *services.ProfileService
+	EmailVerificationService *services.EmailVerificationService
+	FollowService            *services.FollowService
+	BlockService             *services.BlockService
+	LoginGuard               *services.LoginGuard
+	TwoFactorService         *services.TwoFactorService
 }
 
 type User struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Bio       string    `json:"bio"`
-	Premium   bool      `json:"premium"`
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Name          string    `json:"name"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	Bio           string    `json:"bio"`
+	Premium       bool      `json:"premium"`
+	Role          string    `json:"role"`
+	AvatarURL     string    `json:"avatar_url"`
+	Followers     int64     `json:"followers_count"`
+	Following     int64     `json:"following_count"`
+	// Settings and DeletionScheduledAt are only shown to the user themselves.
+	Settings            *UserSettings `json:"settings,omitempty"`
+	DeletionScheduledAt *time.Time    `json:"deletion_scheduled_at,omitempty"`
+	// PendingEmail is set after an email change until the new email is confirmed.
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+type UserSettings struct {
+	WeightUnit        string    `json:"weight_unit"`
+	Timezone          string    `json:"timezone"`
+	ProfileVisibility string    `json:"profile_visibility"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// currentUserFromDB builds the profile the user sees of themselves.
+func currentUserFromDB(user database.GetUserRow, settings database.UserSetting) User {
+	resp := User{
+		ID:            user.ID,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt.Valid,
+		Name:          user.Name,
+		Bio:           user.Bio,
+		Premium:       user.Premium,
+		Role:          user.Role,
+		AvatarURL:     settings.AvatarUrl,
+		Settings: &UserSettings{
+			WeightUnit:        settings.WeightUnit,
+			Timezone:          settings.Timezone,
+			ProfileVisibility: settings.ProfileVisibility,
+			UpdatedAt:         settings.UpdatedAt,
+		},
+	}
+	if user.DeletionScheduledAt.Valid {
+		resp.DeletionScheduledAt = &user.DeletionScheduledAt.Time
+	}
+	return resp
 }
 
 func (h *UserHandler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +97,29 @@ func (h *UserHandler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
 		respondWithError(w, 404, "no user found", err)
 		return
 	}
+	if user.DeletionScheduledAt.Valid {
+		respondWithError(w, 404, "no user found", errors.New("user scheduled for deletion"))
+		return
+	}
+	blocked, err := h.BlockService.IsBlocked(r.Context(), viewerIdFromContext(r), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to check blocks", err)
+		return
+	}
+	if blocked {
+		respondWithError(w, 404, "no user found", services.ErrBlocked)
+		return
+	}
+	settings, err := h.ProfileService.Settings(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get user settings", err)
+		return
+	}
+	counts, err := h.DB.GetFollowCounts(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to count follows", err)
+		return
+	}
 	respondWithJSON(w, 200, User{
 		ID:        user.ID,
 		CreatedAt: user.CreatedAt,
@@ -52,6 +127,10 @@ func (h *UserHandler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
 		Name:      user.Name,
 		Bio:       user.Bio,
 		Premium:   user.Premium,
+		Role:      user.Role,
+		AvatarURL: settings.AvatarUrl,
+		Followers: counts.FollowersCount,
+		Following: counts.FollowingCount,
 	})
 }
 
@@ -70,15 +149,112 @@ func (h *UserHandler) HandleGetCurrentUser(w http.ResponseWriter, r *http.Reques
 		respondWithError(w, 404, "no user found", err)
 		return
 	}
-	respondWithJSON(w, 200, User{
-		ID:        user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email:     user.Email,
-		Name:      user.Name,
-		Bio:       user.Bio,
-		Premium:   user.Premium,
+	settings, err := h.ProfileService.Settings(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to get user settings", err)
+		return
+	}
+	counts, err := h.DB.GetFollowCounts(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, 500, "failed to count follows", err)
+		return
+	}
+	resp := currentUserFromDB(user, settings)
+	resp.Followers = counts.FollowersCount
+	resp.Following = counts.FollowingCount
+	respondWithJSON(w, 200, resp)
+}
+
+// HandleUpdateProfile changes only the fields present in the body. A new email
+// needs the current password and is confirmed by mail before it replaces the
+// current one.
+func (h *UserHandler) HandleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userId := userIdFromContext(r)
+	var req struct {
+		Name              *string `json:"name"`
+		Bio               *string `json:"bio"`
+		Email             *string `json:"email"`
+		AvatarID          *string `json:"avatar_id"`
+		WeightUnit        *string `json:"weight_unit"`
+		Timezone          *string `json:"timezone"`
+		ProfileVisibility *string `json:"profile_visibility"`
+		CurrentPassword   string  `json:"current_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, 400, "bad request", err)
+		return
+	}
+	if req.Email != nil && !validEmail(*req.Email) {
+		respondWithError(w, http.StatusBadRequest, "Invalid email", errors.New("malformed email"))
+		return
+	}
+	// an empty avatar id removes the avatar
+	var avatarId *uuid.UUID
+	if req.AvatarID != nil {
+		id := uuid.Nil
+		if *req.AvatarID != "" {
+			parsed, err := uuid.Parse(*req.AvatarID)
+			if err != nil {
+				respondWithError(w, http.StatusBadRequest, "Invalid avatar id", err)
+				return
+			}
+			id = parsed
+		}
+		avatarId = &id
+	}
+	// the email is what password resets go to, so moving it takes more than a session
+	if req.Email != nil {
+		if _, ok := confirmPassword(w, r, h.DB, h.LoginGuard, userId, req.CurrentPassword); !ok {
+			return
+		}
+	}
+	// a taken email rejects the whole update, checked before anything is saved
+	if req.Email != nil {
+		err := h.EmailVerificationService.CheckEmailAvailable(r.Context(), userId, *req.Email)
+		if errors.Is(err, services.ErrEmailTaken) {
+			respondWithError(w, http.StatusConflict, "Email already exists", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, 500, "failed to check email", err)
+			return
+		}
+	}
+	user, settings, err := h.ProfileService.Update(r.Context(), userId, services.ProfileUpdate{
+		Name:              req.Name,
+		Bio:               req.Bio,
+		AvatarID:          avatarId,
+		WeightUnit:        req.WeightUnit,
+		Timezone:          req.Timezone,
+		ProfileVisibility: req.ProfileVisibility,
 	})
+	switch {
+	case errors.Is(err, services.ErrInvalidName), errors.Is(err, services.ErrInvalidBio),
+		errors.Is(err, services.ErrInvalidWeightUnit),
+		errors.Is(err, services.ErrInvalidTimezone), errors.Is(err, services.ErrInvalidVisibility):
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	case errors.Is(err, services.ErrMediaNotFound):
+		respondWithError(w, http.StatusBadRequest, "Avatar not found, use an image you uploaded", err)
+		return
+	case err != nil:
+		respondWithError(w, 500, "failed to update profile", err)
+		return
+	}
+	resp := currentUserFromDB(user, settings)
+	if req.Email != nil && *req.Email != user.Email {
+		err := h.EmailVerificationService.RequestEmailChange(r.Context(), userId, *req.Email)
+		if errors.Is(err, services.ErrEmailTaken) {
+			respondWithError(w, http.StatusConflict, "Email already exists", err)
+			return
+		}
+		if err != nil {
+			respondWithError(w, 500, "failed to send confirmation email", err)
+			return
+		}
+		resp.PendingEmail = *req.Email
+	}
+	respondWithJSON(w, 200, resp)
 }
 
 func (h *UserHandler) HandlerUpdateBio(w http.ResponseWriter, r *http.Request) {
@@ -104,36 +280,39 @@ func (h *UserHandler) HandlerUpdateBio(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, 200, request)
 }
 
-func (h *UserHandler) HandlerFollow(w http.ResponseWriter, r *http.Request) {
-	userIdString := r.Context().Value(middleware.UserIDKey).(string)
-	userId := uuid.MustParse(userIdString)
-
+func (h *UserHandler) HandleUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("user_id"))
+	if err != nil {
+		respondWithError(w, 400, "incorrect id", err)
+		return
+	}
 	var request struct {
-		ID uuid.UUID `json:"user_id"`
+		Role string `json:"role"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		respondWithError(w, 400, "failed to decode user", err)
+		respondWithError(w, 400, "failed to decode role", err)
+		return
+	}
+	if !auth.ValidRole(request.Role) {
+		respondWithError(w, 400, "unknown role", errors.New("invalid role"))
 		return
 	}
-	err := h.DB.FollowUser(r.Context(), database.FollowUserParams{
-		FollowerID: userId,
-		FollowedID: request.ID,
+	updated, err := h.DB.UpdateUserRole(r.Context(), database.UpdateUserRoleParams{
+		ID:   userId,
+		Role: request.Role,
 	})
 	if err != nil {
-		respondWithError(w, 500, fmt.Sprintf("failed to follow: %v", err), err)
+		respondWithError(w, 500, "failed to update role", err)
 		return
 	}
-	respondWithJSON(w, 200, request)
-}
-
-func (h *UserHandler) HandlerGetFollowedUsers(w http.ResponseWriter, r *http.Request) {
-	fmt.Print("gettin followings..")
-	userIdString := r.Context().Value(middleware.UserIDKey).(string)
-	userId := uuid.MustParse(userIdString)
-	followedUsers, err := h.DB.GetFollowedUsers(r.Context(), userId)
-	if err != nil {
-		respondWithError(w, 500, fmt.Sprintf("failed to get follows: %v", err), err)
+	if updated == 0 {
+		respondWithError(w, 404, "no user found", errors.New("no user"))
+		return
+	}
+	// force a new login so the old role does not survive in refresh tokens
+	if err := h.DB.DeleteUserRefreshToken(r.Context(), userId); err != nil {
+		respondWithError(w, 500, "failed to revoke tokens", err)
 		return
 	}
-	respondWithJSON(w, 200, followedUsers)
+	respondWithJSON(w, 200, request)
 }
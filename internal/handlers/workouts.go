@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
 	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/middleware"
 )
 
 type WorkoutHandler struct {
@@ -71,18 +73,32 @@ func (h *WorkoutHandler) HandleCreateWorkout(w http.ResponseWriter, r *http.Requ
 
 func (h *WorkoutHandler) HandleGetMyWorkouts(w http.ResponseWriter, r *http.Request) {
 	userId := userIdFromContext(r)
-	workouts, err := h.DB.GetUsersWorkouts(r.Context(), userId)
+	page, err := pageFromRequest(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	workouts, err := h.DB.GetUsersWorkouts(r.Context(), database.GetUsersWorkoutsParams{
+		UserID:          userId,
+		CursorCreatedAt: page.CreatedAt,
+		CursorID:        page.ID,
+		PageSize:        page.limit(),
+	})
 	if err != nil {
 		respondWithError(w, 500, "failed to get workouts", err)
 		return
 	}
 	var resp struct {
-		Workouts []Workout `json:"workouts"`
+		Workouts   []Workout `json:"workouts"`
+		NextCursor *string   `json:"next_cursor"`
 	}
+	workouts, resp.NextCursor = paginate(workouts, page, func(w database.Workout) (time.Time, uuid.UUID) {
+		return w.CreatedAt, w.ID
+	})
 	for _, w := range workouts {
 		resp.Workouts = append(resp.Workouts, Workout{
 			ID:           w.ID,
-			CreatedAt:    w.CreatedAt.Time,
+			CreatedAt:    w.CreatedAt,
 			ProgramDayId: w.ProgramDayID,
 		})
 	}
@@ -105,10 +121,15 @@ func (h *WorkoutHandler) HandleGetWorkout(w http.ResponseWriter, r *http.Request
 		respondWithError(w, 500, "failed to get workout", err)
 		return
 	}
+	if workout.UserID != userIdFromContext(r) && !middleware.HasRole(r.Context(), auth.RoleAdmin) {
+		respondWithError(w, http.StatusForbidden, "not your workout", errors.New("workout belongs to another user"))
+		return
+	}
 	resp := Workout{
 		ID:           workout.ID,
+		UserId:       workout.UserID,
 		ProgramDayId: workout.ProgramDayID,
-		CreatedAt:    workout.CreatedAt.Time,
+		CreatedAt:    workout.CreatedAt,
 	}
 	lifts, err := h.DB.GetWorkoutLifts(r.Context(), workout.ID)
 	if err != nil {
@@ -0,0 +1,39 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogMailer_Send(t *testing.T) {
+	t.Run("should append emails to the file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mail.log")
+		mailer := NewLogMailer(path, "noreply@fitterby.com")
+
+		err := mailer.Send(context.Background(), Message{To: "first@fitterby.com", Subject: "First", Body: "hello"})
+		require.NoError(t, err)
+		err = mailer.Send(context.Background(), Message{To: "second@fitterby.com", Subject: "Second", Body: "world"})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "To: first@fitterby.com\r\n")
+		assert.Contains(t, string(data), "To: second@fitterby.com\r\n")
+		assert.Contains(t, string(data), "From: noreply@fitterby.com\r\n")
+	})
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	t.Run("should reject header injection", func(t *testing.T) {
+		mailer := NewSMTPMailer("localhost", "25", "", "", "noreply@fitterby.com")
+
+		err := mailer.Send(context.Background(), Message{To: "user@fitterby.com\r\nBcc: victim@fitterby.com", Subject: "Hi"})
+
+		assert.Error(t, err)
+	})
+}
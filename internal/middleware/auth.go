@@ -3,6 +3,7 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/sssseraphim/fitterBy/internal/auth"
@@ -14,29 +15,81 @@ const (
 	UserIDKey   contextKey = "user_id"
 	UserTypeKey contextKey = "user_type"
 	EmailKey    contextKey = "email"
+	// ScopesKey is only set for requests authenticated with an API key or a
+	// third-party app token.
+	ScopesKey contextKey = "scopes"
+
+	requiredScopeKey contextKey = "required_scope"
 )
 
-func AuthMiddleware(jwtConfig *auth.JWTConfig) func(http.Handler) http.Handler {
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, []string, error)
+}
+
+// AuthMiddleware accepts "Authorization: Bearer <access token>" and, when
+// apiKeys is set, "Authorization: ApiKey <key>". API keys only get through on
+// routes wrapped by AllowScope with a scope the key has, the same goes for
+// access tokens issued to third-party apps. Without the header
+// the access token cookie is used, and unsafe methods then need a CSRF token.
+func AuthMiddleware(jwtConfig *auth.JWTConfig, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, `{"error": "Authorization header required"}`, http.StatusUnauthorized)
-				return
+				cookie, err := r.Cookie(AccessTokenCookie)
+				if err != nil {
+					http.Error(w, `{"error": "Authorization header required"}`, http.StatusUnauthorized)
+					return
+				}
+				if !safeMethod(r.Method) && !ValidCSRF(r) {
+					http.Error(w, `{"error": "CSRF token missing or invalid"}`, http.StatusForbidden)
+					return
+				}
+				authHeader = "Bearer " + cookie.Value
 			}
 			parts := strings.Split(authHeader, " ")
-			if len(parts) != 2 || parts[0] != "Bearer" {
+			if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey") {
 				http.Error(w, `{"error": "Authorization header invalid"}`, http.StatusUnauthorized)
 				return
 			}
-			tokenString := parts[1]
-			claims, valid, err := jwtConfig.ValidateAccessToken(tokenString)
-			if err != nil || !valid {
-				http.Error(w, `{"error": "Token invalid or expired"}`, http.StatusUnauthorized)
-				return
+			ctx := r.Context()
+			var claims *auth.Claims
+			if parts[0] == "ApiKey" {
+				if apiKeys == nil {
+					http.Error(w, `{"error": "Authorization header invalid"}`, http.StatusUnauthorized)
+					return
+				}
+				var scopes []string
+				var err error
+				claims, scopes, err = apiKeys.AuthenticateAPIKey(ctx, parts[1])
+				if err != nil {
+					http.Error(w, `{"error": "API key invalid or expired"}`, http.StatusUnauthorized)
+					return
+				}
+				if !scopeAllowed(ctx, scopes) {
+					http.Error(w, `{"error": "API key not allowed for this endpoint"}`, http.StatusForbidden)
+					return
+				}
+				ctx = context.WithValue(ctx, ScopesKey, scopes)
+			} else {
+				var valid bool
+				var err error
+				claims, valid, err = jwtConfig.ValidateAccessToken(parts[1])
+				if err != nil || !valid {
+					http.Error(w, `{"error": "Token invalid or expired"}`, http.StatusUnauthorized)
+					return
+				}
+				if claims.ClientID != "" || len(claims.Audience) > 0 {
+					scopes := strings.Fields(claims.Scope)
+					if !scopeAllowed(ctx, scopes) {
+						http.Error(w, `{"error": "Token not allowed for this endpoint"}`, http.StatusForbidden)
+						return
+					}
+					ctx = context.WithValue(ctx, ScopesKey, scopes)
+				}
 			}
 
-			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, UserIDKey, claims.UserID)
 			ctx = context.WithValue(ctx, UserTypeKey, claims.UserType)
 			ctx = context.WithValue(ctx, EmailKey, claims.Email)
 
@@ -44,3 +97,38 @@ func AuthMiddleware(jwtConfig *auth.JWTConfig) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+func scopeAllowed(ctx context.Context, scopes []string) bool {
+	required, ok := ctx.Value(requiredScopeKey).(string)
+	return ok && slices.Contains(scopes, required)
+}
+
+// AllowScope opens the wrapped route to API keys and third-party apps that
+// have scope. It has to wrap AuthMiddleware.
+func AllowScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), requiredScopeKey, scope)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// OptionalAuth runs requests with credentials through authMiddleware and lets
+// anonymous ones through unchanged, for routes anybody can read but where the
+// caller changes what they see. Invalid credentials are still rejected so
+// clients notice an expired token.
+func OptionalAuth(authMiddleware func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		authenticated := authMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				if _, err := r.Cookie(AccessTokenCookie); err != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			authenticated.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sssseraphim/fitterBy/internal/auth"
+)
+
+type fakeAPIKeys map[string][]string
+
+func (f fakeAPIKeys) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, []string, error) {
+	scopes, ok := f[key]
+	if !ok {
+		return nil, nil, errors.New("unknown key")
+	}
+	return &auth.Claims{UserID: "user-1", UserType: auth.RoleUser}, scopes, nil
+}
+
+func TestAllowScopes(t *testing.T) {
+	jwtConfig := &auth.JWTConfig{AccessTokenSecret: "secret"}
+	requireAuth := AuthMiddleware(jwtConfig, fakeAPIKeys{"fby_read": {auth.ScopeWorkoutsRead}})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		header  string
+		want    int
+	}{
+		{"scope granted", AllowScope(auth.ScopeWorkoutsRead)(requireAuth(ok)), "ApiKey fby_read", http.StatusOK},
+		{"scope missing", AllowScope(auth.ScopeWorkoutsWrite)(requireAuth(ok)), "ApiKey fby_read", http.StatusForbidden},
+		{"route without scope", requireAuth(ok), "ApiKey fby_read", http.StatusForbidden},
+		{"unknown key", AllowScope(auth.ScopeWorkoutsRead)(requireAuth(ok)), "ApiKey fby_nope", http.StatusUnauthorized},
+		{"bad token", requireAuth(ok), "Bearer nope", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", tt.header)
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieAuthRequiresCSRF(t *testing.T) {
+	jwtConfig := &auth.JWTConfig{AccessTokenSecret: "secret", AccessTokenExpiry: time.Minute}
+	token, err := jwtConfig.GenerateAccessToken("user-1", auth.RoleUser, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := AuthMiddleware(jwtConfig, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name   string
+		method string
+		csrf   string
+		want   int
+	}{
+		{"safe method", http.MethodGet, "", http.StatusOK},
+		{"missing csrf", http.MethodPost, "", http.StatusForbidden},
+		{"wrong csrf", http.MethodPost, "other", http.StatusForbidden},
+		{"matching csrf", http.MethodPost, "csrf-value", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: token})
+			req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: "csrf-value"})
+			if tt.csrf != "" {
+				req.Header.Set(CSRFHeader, tt.csrf)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppTokenScopes(t *testing.T) {
+	jwtConfig := &auth.JWTConfig{AccessTokenSecret: "secret", AccessTokenExpiry: time.Minute}
+	token, err := jwtConfig.GenerateClientAccessToken("user-1", auth.RoleUser, "user@example.com", "fbc_app", []string{auth.ScopeWorkoutsWrite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireAuth := AuthMiddleware(jwtConfig, nil)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		want    int
+	}{
+		{"scope granted", AllowScope(auth.ScopeWorkoutsWrite)(requireAuth(ok)), http.StatusOK},
+		{"scope missing", AllowScope(auth.ScopeProfileRead)(requireAuth(ok)), http.StatusForbidden},
+		{"route without scope", requireAuth(ok), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	jwtConfig := &auth.JWTConfig{AccessTokenSecret: "secret", AccessTokenExpiry: time.Minute}
+	token, err := jwtConfig.GenerateAccessToken("user-1", auth.RoleUser, "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotUser any
+	handler := OptionalAuth(AuthMiddleware(jwtConfig, nil))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser = r.Context().Value(UserIDKey)
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		want     int
+		wantUser any
+	}{
+		{"anonymous", "", http.StatusOK, nil},
+		{"valid token", "Bearer " + token, http.StatusOK, "user-1"},
+		{"bad token", "Bearer nope", http.StatusUnauthorized, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUser = nil
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want || gotUser != tt.wantUser {
+				t.Errorf("got status %d and user %v, want %d and %v", rec.Code, gotUser, tt.want, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP takes the client address from header, set by the reverse proxy in
+// front of the server, instead of the address of the proxy. For lists like
+// X-Forwarded-For the last entry is used, the one the proxy appended, as
+// clients can send earlier ones themselves. The server must only be
+// reachable through the proxy, anyone else could set the header too.
+func ClientIP(header string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			values := r.Header.Values(header)
+			if len(values) > 0 {
+				addrs := strings.Split(values[len(values)-1], ",")
+				ip := strings.TrimSpace(addrs[len(addrs)-1])
+				if net.ParseIP(ip) != nil {
+					r = r.Clone(r.Context())
+					r.RemoteAddr = net.JoinHostPort(ip, "0")
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
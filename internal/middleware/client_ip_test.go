@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		want    string
+	}{
+		{"without header", nil, "10.0.0.1:1234"},
+		{"single address", []string{"203.0.113.7"}, "203.0.113.7:0"},
+		{"last entry wins", []string{"198.51.100.1, 203.0.113.7"}, "203.0.113.7:0"},
+		{"last header wins", []string{"198.51.100.1", "203.0.113.7"}, "203.0.113.7:0"},
+		{"ipv6", []string{"2001:db8::1"}, "[2001:db8::1]:0"},
+		{"not an address", []string{"unknown"}, "10.0.0.1:1234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := ClientIP("X-Forwarded-For")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.RemoteAddr
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			for _, h := range tt.headers {
+				req.Header.Add("X-Forwarded-For", h)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("RemoteAddr = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Cookie sessions for the bundled UI. The access and refresh cookies are
+// HttpOnly; the CSRF cookie is readable by the page, which echoes it back in
+// CSRFHeader on state-changing requests (double-submit).
+const (
+	AccessTokenCookie  = "fitterby_access"
+	RefreshTokenCookie = "fitterby_refresh"
+	CSRFCookie         = "fitterby_csrf"
+	CSRFHeader         = "X-CSRF-Token"
+)
+
+// ValidCSRF reports whether the CSRF header matches the CSRF cookie.
+func ValidCSRF(r *http.Request) bool {
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// RequireRole only lets through users whose role is one of roles.
+// It has to be wrapped by AuthMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasRole(r.Context(), roles...) {
+				http.Error(w, `{"error": "Insufficient permissions"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func HasRole(ctx context.Context, roles ...string) bool {
+	role, ok := ctx.Value(UserTypeKey).(string)
+	if !ok {
+		return false
+	}
+	return slices.Contains(roles, role)
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type EmailVerifier interface {
+	IsVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+// RequireVerifiedEmail rejects users that haven't confirmed their email yet.
+// It has to be wrapped by AuthMiddleware.
+func RequireVerifiedEmail(verifier EmailVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userId, err := uuid.Parse(r.Context().Value(UserIDKey).(string))
+			if err != nil {
+				http.Error(w, `{"error": "Token invalid or expired"}`, http.StatusUnauthorized)
+				return
+			}
+			verified, err := verifier.IsVerified(r.Context(), userId)
+			if err != nil {
+				http.Error(w, `{"error": "Failed to check email verification"}`, http.StatusInternalServerError)
+				return
+			}
+			if !verified {
+				http.Error(w, `{"error": "Email not verified"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
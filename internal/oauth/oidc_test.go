@@ -0,0 +1,138 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockOIDCServer is a minimal OpenID Connect provider. It hands out one code
+// bound to the PKCE challenge and nonce of the last authorization request.
+type mockOIDCServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	audience string
+
+	code      string
+	challenge string
+	nonce     string
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCServer{key: key, audience: "client-id", code: "good-code"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discoveryDocument{
+			Issuer:                m.URL,
+			AuthorizationEndpoint: m.URL + "/authorize",
+			TokenEndpoint:         m.URL + "/token",
+			JWKSURI:               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: "mock-key",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != m.code || CodeChallenge(r.Form.Get("code_verifier")) != m.challenge {
+			http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idTokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    m.URL,
+				Subject:   "subject-1",
+				Audience:  jwt.ClaimStrings{m.audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+			},
+			Nonce:         m.nonce,
+			Email:         "user@example.com",
+			EmailVerified: true,
+			Name:          "Jane Doe",
+		})
+		token.Header["kid"] = "mock-key"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// authorize plays the browser: it reads the challenge and nonce from the
+// authorization URL the way the provider would.
+func (m *mockOIDCServer) authorize(t *testing.T, authURL string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("code_challenge_method") != "S256" {
+		t.Fatalf("expected S256 challenge, got %q", u.Query().Get("code_challenge_method"))
+	}
+	m.challenge = u.Query().Get("code_challenge")
+	m.nonce = u.Query().Get("nonce")
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	ctx := context.Background()
+	server := newMockOIDCServer(t)
+	provider := NewOIDCProvider(OIDCConfig{
+		Name:        "mock",
+		Issuer:      server.URL,
+		ClientID:    "client-id",
+		RedirectURL: "http://localhost:8080/api/auth/oauth/mock/callback",
+	})
+
+	verifier := NewCodeVerifier()
+	authURL, err := provider.AuthCodeURL(ctx, "state", CodeChallenge(verifier), "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.authorize(t, authURL)
+
+	identity, err := provider.Exchange(ctx, "good-code", verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	if identity.Subject != "subject-1" || identity.Email != "user@example.com" || !identity.EmailVerified {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	if _, err := provider.Exchange(ctx, "good-code", NewCodeVerifier(), "nonce-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("wrong code verifier: got %v, want ErrExchangeFailed", err)
+	}
+	if _, err := provider.Exchange(ctx, "good-code", verifier, "nonce-2"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("wrong nonce: got %v, want ErrExchangeFailed", err)
+	}
+
+	server.audience = "someone-else"
+	if _, err := provider.Exchange(ctx, "good-code", verifier, "nonce-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("wrong audience: got %v, want ErrExchangeFailed", err)
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+var (
+	ErrDeletionScheduled    = errors.New("account deletion already scheduled")
+	ErrDeletionNotScheduled = errors.New("account deletion not scheduled")
+)
+
+// purgeBatchSize caps how many accounts one purge run deletes.
+const purgeBatchSize = 50
+
+type AccountService struct {
+	DB          *database.Queries
+	Conn        *sql.DB
+	Media       *MediaService
+	GracePeriod time.Duration
+}
+
+func NewAccountService(db *database.Queries, conn *sql.DB, media *MediaService, gracePeriod time.Duration) *AccountService {
+	return &AccountService{DB: db, Conn: conn, Media: media, GracePeriod: gracePeriod}
+}
+
+// ScheduleDeletion marks the account for deletion after the grace period and
+// signs it out everywhere. Logging in again stays possible so the user can
+// cancel.
+func (s *AccountService) ScheduleDeletion(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	deleteAt := time.Now().Add(s.GracePeriod)
+	updated, err := s.DB.ScheduleUserDeletion(ctx, database.ScheduleUserDeletionParams{
+		ID:                  userID,
+		DeletionScheduledAt: deleteAt,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if updated == 0 {
+		return time.Time{}, ErrDeletionScheduled
+	}
+	if err := s.DB.DeleteUserRefreshToken(ctx, userID); err != nil {
+		return time.Time{}, err
+	}
+	return deleteAt, nil
+}
+
+func (s *AccountService) CancelDeletion(ctx context.Context, userID uuid.UUID) error {
+	updated, err := s.DB.CancelUserDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrDeletionNotScheduled
+	}
+	return nil
+}
+
+// PurgeDueAccounts deletes the accounts whose grace period is over and
+// returns how many were deleted. An account failing to purge is logged and
+// skipped, so it doesn't hold up the ones due after it.
+func (s *AccountService) PurgeDueAccounts(ctx context.Context) (int, error) {
+	ids, err := s.DB.GetUsersDueForPurge(ctx, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, id := range ids {
+		if err := s.purge(ctx, id); err != nil {
+			log.Printf("failed to purge account %s: %v", id, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge deletes one account in a transaction. The user row is locked first
+// so a restore racing the purge either wins or waits.
+func (s *AccountService) purge(ctx context.Context, userID uuid.UUID) error {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	q := s.DB.WithTx(tx)
+
+	if _, err := q.LockUserForPurge(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// restored in the meantime
+			return nil
+		}
+		return err
+	}
+	// the uploads are public by URL, so the stored files go before the rows
+	// pointing to them. Deleting them again is fine if the purge is retried.
+	files, err := q.GetMediaFilesOfUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.Media.DeleteFiles(ctx, files); err != nil {
+		return err
+	}
+	steps := []func(context.Context, uuid.UUID) error{
+		q.DeletePlainRepostsOfUser,
+		q.PurgeUserCommentRevisions,
+		q.DetachUserComments,
+		q.PurgeUserPostsLikes,
+		q.PurgeUserPostsComments,
+		q.PurgeUserFollows,
+		q.PurgeUserWorkouts,
+		q.PurgeUser,
+	}
+	for _, step := range steps {
+		if err := step(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RunPurger purges due accounts every interval until ctx is done.
+func (s *AccountService) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := s.PurgeDueAccounts(ctx)
+		if err != nil {
+			log.Printf("failed to purge deleted accounts: %v", err)
+		} else if purged > 0 {
+			log.Printf("purged %d deleted accounts", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Export writes everything stored about the user as a zip of JSON files.
+func (s *AccountService) Export(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	files := []struct {
+		name  string
+		query func(context.Context, uuid.UUID) (json.RawMessage, error)
+	}{
+		{"profile.json", s.DB.ExportUserProfile},
+		{"posts.json", s.DB.ExportUserPosts},
+		{"comments.json", s.DB.ExportUserComments},
+		{"likes.json", s.DB.ExportUserLikes},
+		{"follows.json", s.DB.ExportUserFollows},
+		{"exercises.json", s.DB.ExportUserExercises},
+		{"programs.json", s.DB.ExportUserPrograms},
+		{"subscriptions.json", s.DB.ExportUserSubscriptions},
+		{"workouts.json", s.DB.ExportUserWorkouts},
+		{"lifts.json", s.DB.ExportUserLifts},
+	}
+	// query everything first so a failure doesn't leave half a zip behind
+	contents := make([]json.RawMessage, len(files))
+	for i, f := range files {
+		data, err := f.query(ctx, userID)
+		if err != nil {
+			return err
+		}
+		contents[i] = data
+	}
+
+	zw := zip.NewWriter(w)
+	for i, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(contents[i]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
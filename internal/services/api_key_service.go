@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const apiKeyPrefix = "fby_"
+
+var (
+	ErrInvalidAPIKey  = errors.New("api key invalid or expired")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+type APIKeyService struct {
+	DB *database.Queries
+}
+
+func NewAPIKeyService(db *database.Queries) *APIKeyService {
+	return &APIKeyService{DB: db}
+}
+
+// Create returns the new key in plain text, only its hash is stored.
+func (s *APIKeyService) Create(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt sql.NullTime) (string, database.ApiKey, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", database.ApiKey{}, err
+	}
+	key := apiKeyPrefix + token
+	apiKey, err := s.DB.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		UserID:    userID,
+		Name:      name,
+		KeyPrefix: key[:len(apiKeyPrefix)+6],
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return "", database.ApiKey{}, err
+	}
+	return key, apiKey, nil
+}
+
+func (s *APIKeyService) Delete(ctx context.Context, userID, keyID uuid.UUID) error {
+	deleted, err := s.DB.DeleteUserAPIKey(ctx, database.DeleteUserAPIKeyParams{
+		ID:     keyID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey returns claims of the key owner and the scopes of the key.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, []string, error) {
+	apiKey, err := s.DB.GetAPIKeyByHash(ctx, hashToken(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if apiKey.ExpiresAt.Valid && time.Now().After(apiKey.ExpiresAt.Time) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err := s.DB.TouchAPIKey(ctx, apiKey.ID); err != nil {
+		return nil, nil, err
+	}
+	claims := &auth.Claims{
+		UserID:   apiKey.UserID.String(),
+		UserType: apiKey.Role,
+		Email:    apiKey.Email,
+	}
+	return claims, apiKey.Scopes, nil
+}
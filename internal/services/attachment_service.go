@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+var (
+	ErrWorkoutNotFound = errors.New("workout not found")
+	ErrProgramNotFound = errors.New("program not found")
+	ErrBothAttachments = errors.New("a post shares a workout or a program, not both")
+)
+
+// WorkoutSummary is the workout embedded in a post sharing it.
+type WorkoutSummary struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	CreatedAt time.Time
+	// The program day and program are left empty for viewers who may not
+	// see the program.
+	DayName     string
+	ProgramID   uuid.NullUUID
+	ProgramName string
+	Lifts       []WorkoutLift
+	// TotalVolume sums weight times sets times reps of the lifts.
+	TotalVolume int64
+	PRs         int
+}
+
+type WorkoutLift struct {
+	Exercise string
+	Weight   int32
+	Sets     int32
+	Reps     int32
+	Volume   int64
+	PR       bool
+}
+
+// PostAttachments are the summaries of the workouts and programs shared by
+// a list of posts, by id. Programs the viewer may not see are left out.
+type PostAttachments struct {
+	Workouts map[uuid.UUID]WorkoutSummary
+	Programs map[uuid.UUID]database.GetProgramCardsRow
+}
+
+// AttachmentService checks and loads the workouts and programs shared in
+// posts. Authors share their own workouts, and programs they may see.
+// Workouts are shown to everyone who sees the post, programs keep their own
+// visibility.
+type AttachmentService struct {
+	DB         *database.Queries
+	Visibility *VisibilityService
+}
+
+func NewAttachmentService(db *database.Queries, visibility *VisibilityService) *AttachmentService {
+	return &AttachmentService{DB: db, Visibility: visibility}
+}
+
+// Check makes sure the user may share the workout or program in a post.
+func (s *AttachmentService) Check(ctx context.Context, userID uuid.UUID, workoutID, programID uuid.NullUUID) error {
+	if workoutID.Valid && programID.Valid {
+		return ErrBothAttachments
+	}
+	if workoutID.Valid {
+		workout, err := s.DB.GetWorkoutByID(ctx, workoutID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWorkoutNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if workout.UserID != userID {
+			return ErrWorkoutNotFound
+		}
+	}
+	if programID.Valid {
+		program, err := s.DB.GetProgram(ctx, programID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProgramNotFound
+		}
+		if err != nil {
+			return err
+		}
+		allowed, err := s.Visibility.CanViewPost(ctx, uuid.NullUUID{UUID: userID, Valid: true}, program.UserID, program.Visibility)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrProgramNotFound
+		}
+	}
+	return nil
+}
+
+// Load returns the summaries of the workouts and programs as seen by the
+// viewer, who is not valid for anonymous requests.
+func (s *AttachmentService) Load(ctx context.Context, viewerID uuid.NullUUID, workoutIDs, programIDs []uuid.UUID) (PostAttachments, error) {
+	attachments := PostAttachments{
+		Workouts: map[uuid.UUID]WorkoutSummary{},
+		Programs: map[uuid.UUID]database.GetProgramCardsRow{},
+	}
+	if len(workoutIDs) > 0 {
+		rows, err := s.DB.GetWorkoutSummaries(ctx, workoutIDs)
+		if err != nil {
+			return PostAttachments{}, err
+		}
+		visible := map[uuid.UUID]bool{}
+		for _, row := range rows {
+			if _, ok := visible[row.ProgramID]; ok {
+				continue
+			}
+			allowed, err := s.Visibility.CanViewPost(ctx, viewerID, row.ProgramAuthorID, row.ProgramVisibility)
+			if err != nil {
+				return PostAttachments{}, err
+			}
+			visible[row.ProgramID] = allowed
+		}
+		for _, w := range summarizeWorkouts(rows, visible) {
+			attachments.Workouts[w.ID] = w
+		}
+	}
+	if len(programIDs) > 0 {
+		programs, err := s.DB.GetProgramCards(ctx, programIDs)
+		if err != nil {
+			return PostAttachments{}, err
+		}
+		for _, p := range programs {
+			allowed, err := s.Visibility.CanViewPost(ctx, viewerID, p.UserID, p.Visibility)
+			if err != nil {
+				return PostAttachments{}, err
+			}
+			if allowed {
+				attachments.Programs[p.ID] = p
+			}
+		}
+	}
+	return attachments, nil
+}
+
+// summarizeWorkouts groups the lift rows of GetWorkoutSummaries, which come
+// ordered by workout, into summaries. visiblePrograms tells which programs
+// the viewer may see.
+func summarizeWorkouts(rows []database.GetWorkoutSummariesRow, visiblePrograms map[uuid.UUID]bool) []WorkoutSummary {
+	var summaries []WorkoutSummary
+	for _, row := range rows {
+		if len(summaries) == 0 || summaries[len(summaries)-1].ID != row.WorkoutID {
+			summary := WorkoutSummary{
+				ID:        row.WorkoutID,
+				UserID:    row.UserID,
+				CreatedAt: row.CreatedAt,
+				Lifts:     []WorkoutLift{},
+			}
+			if visiblePrograms[row.ProgramID] {
+				summary.DayName = row.DayName
+				summary.ProgramID = uuid.NullUUID{UUID: row.ProgramID, Valid: true}
+				summary.ProgramName = row.ProgramName
+			}
+			summaries = append(summaries, summary)
+		}
+		if !row.Weight.Valid {
+			continue
+		}
+		w := &summaries[len(summaries)-1]
+		lift := WorkoutLift{
+			Exercise: row.ExerciseName.String,
+			Weight:   row.Weight.Int32,
+			Sets:     row.Sets.Int32,
+			Reps:     row.Reps.Int32,
+			Volume:   int64(row.Weight.Int32) * int64(row.Sets.Int32) * int64(row.Reps.Int32),
+			PR:       row.Pr,
+		}
+		w.Lifts = append(w.Lifts, lift)
+		w.TotalVolume += lift.Volume
+		if lift.PR {
+			w.PRs++
+		}
+	}
+	return summaries
+}
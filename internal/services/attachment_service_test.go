@@ -0,0 +1,52 @@
+package services
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+func TestSummarizeWorkouts(t *testing.T) {
+	squat, rest := uuid.New(), uuid.New()
+	visible, hidden := uuid.New(), uuid.New()
+	lift := func(workout, program uuid.UUID, exercise string, weight, sets, reps int32, pr bool) database.GetWorkoutSummariesRow {
+		return database.GetWorkoutSummariesRow{
+			WorkoutID:    workout,
+			ProgramID:    program,
+			ProgramName:  "5x5",
+			DayName:      "Day A",
+			ExerciseName: sql.NullString{String: exercise, Valid: true},
+			Weight:       sql.NullInt32{Int32: weight, Valid: true},
+			Sets:         sql.NullInt32{Int32: sets, Valid: true},
+			Reps:         sql.NullInt32{Int32: reps, Valid: true},
+			Pr:           pr,
+		}
+	}
+	rows := []database.GetWorkoutSummariesRow{
+		lift(squat, visible, "Squat", 100, 5, 5, true),
+		lift(squat, visible, "Bench", 60, 5, 5, false),
+		{WorkoutID: rest, ProgramID: hidden, ProgramName: "secret", DayName: "Day B"},
+	}
+
+	got := summarizeWorkouts(rows, map[uuid.UUID]bool{visible: true, hidden: false})
+
+	if len(got) != 2 {
+		t.Fatalf("got %d workouts, want 2", len(got))
+	}
+	w := got[0]
+	if len(w.Lifts) != 2 || w.Lifts[0].Volume != 2500 || w.TotalVolume != 4000 || w.PRs != 1 {
+		t.Errorf("workout = %+v, want 2 lifts with 4000 volume and 1 PR", w)
+	}
+	if w.ProgramName != "5x5" || w.DayName != "Day A" || w.ProgramID.UUID != visible {
+		t.Errorf("program of the workout = %v %q %q", w.ProgramID, w.ProgramName, w.DayName)
+	}
+	w = got[1]
+	if len(w.Lifts) != 0 || w.TotalVolume != 0 {
+		t.Errorf("workout without lifts = %+v", w)
+	}
+	if w.ProgramID.Valid || w.ProgramName != "" || w.DayName != "" {
+		t.Errorf("hidden program shows up: %v %q %q", w.ProgramID, w.ProgramName, w.DayName)
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+var (
+	ErrCannotBlockSelf = errors.New("users can't block or mute themselves")
+	ErrBlocked         = errors.New("one of the users blocked the other")
+	ErrNotBlocked      = errors.New("user is not blocked")
+	ErrNotMuted        = errors.New("user is not muted")
+)
+
+type BlockService struct {
+	DB *database.Queries
+}
+
+func NewBlockService(db *database.Queries) *BlockService {
+	return &BlockService{DB: db}
+}
+
+// Block blocks the user and drops any follow between the two, in both
+// directions and including pending requests.
+func (s *BlockService) Block(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	if err := s.checkTarget(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	_, err := s.DB.BlockUser(ctx, database.BlockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return err
+	}
+	return s.DB.DeleteFollowsBetween(ctx, database.DeleteFollowsBetweenParams{
+		FollowerID: blockerID,
+		FollowedID: blockedID,
+	})
+}
+
+func (s *BlockService) Unblock(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	deleted, err := s.DB.UnblockUser(ctx, database.UnblockUserParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// Mute hides the user's posts from the muter's feeds, nothing else changes.
+func (s *BlockService) Mute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	if err := s.checkTarget(ctx, muterID, mutedID); err != nil {
+		return err
+	}
+	_, err := s.DB.MuteUser(ctx, database.MuteUserParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	return err
+}
+
+func (s *BlockService) Unmute(ctx context.Context, muterID, mutedID uuid.UUID) error {
+	deleted, err := s.DB.UnmuteUser(ctx, database.UnmuteUserParams{
+		MuterID: muterID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotMuted
+	}
+	return nil
+}
+
+func (s *BlockService) checkTarget(ctx context.Context, userID, targetID uuid.UUID) error {
+	if userID == targetID {
+		return ErrCannotBlockSelf
+	}
+	_, err := s.DB.GetUser(ctx, targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// IsBlocked reports whether either user blocked the other. Anonymous viewers
+// are never blocked.
+func (s *BlockService) IsBlocked(ctx context.Context, viewerID uuid.NullUUID, userID uuid.UUID) (bool, error) {
+	if !viewerID.Valid || viewerID.UUID == userID {
+		return false, nil
+	}
+	return s.DB.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+		BlockerID: viewerID.UUID,
+		BlockedID: userID,
+	})
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/mail"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("verification token invalid or expired")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailTaken               = errors.New("email already in use")
+)
+
+type EmailVerificationService struct {
+	DB      *database.Queries
+	Mailer  mail.Mailer
+	BaseURL string
+	Expiry  time.Duration
+}
+
+func NewEmailVerificationService(db *database.Queries, mailer mail.Mailer, baseURL string, expiry time.Duration) *EmailVerificationService {
+	return &EmailVerificationService{
+		DB:      db,
+		Mailer:  mailer,
+		BaseURL: baseURL,
+		Expiry:  expiry,
+	}
+}
+
+// SendVerification emails a verification link for the current email of the user.
+// Links sent earlier stop working.
+func (s *EmailVerificationService) SendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.DB.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt.Valid {
+		return ErrEmailAlreadyVerified
+	}
+	if err := s.DB.DeleteUserEmailVerificationTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = s.DB.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.Expiry),
+	})
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your fitterBy email",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your email by opening the link below, it is valid for %v:\n\n"+
+			"%s/api/auth/verify?token=%s\n", user.Name, s.Expiry, s.BaseURL, url.QueryEscape(token)),
+	})
+}
+
+// CheckEmailAvailable returns ErrEmailTaken when another account uses the
+// email.
+func (s *EmailVerificationService) CheckEmailAvailable(ctx context.Context, userID uuid.UUID, email string) error {
+	user, err := s.DB.GetUserLogin(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.ID != userID {
+		return ErrEmailTaken
+	}
+	return nil
+}
+
+// RequestEmailChange emails a confirmation link to the new address. The
+// account keeps its current email until the link is opened.
+func (s *EmailVerificationService) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string) error {
+	user, err := s.DB.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.GetUserLogin(ctx, newEmail)
+	if err == nil {
+		return ErrEmailTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err := s.DB.DeleteUserEmailVerificationTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = s.DB.CreateEmailVerificationToken(ctx, database.CreateEmailVerificationTokenParams{
+		UserID:      user.ID,
+		Email:       newEmail,
+		TokenHash:   hashToken(token),
+		ExpiresAt:   time.Now().Add(s.Expiry),
+		EmailChange: true,
+	})
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, mail.Message{
+		To:      newEmail,
+		Subject: "Confirm your new fitterBy email",
+		Body: fmt.Sprintf("Hi %s,\n\nplease confirm your new email by opening the link below, it is valid for %v:\n\n"+
+			"%s/api/auth/verify?token=%s\n", user.Name, s.Expiry, s.BaseURL, url.QueryEscape(token)),
+	})
+}
+
+// Verify consumes the token and marks the email it was issued for as
+// verified. Tokens of an email change switch the account to the new email.
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) error {
+	row, err := s.DB.ConsumeEmailVerificationToken(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+	if row.EmailChange {
+		return s.changeEmail(ctx, row.UserID, row.Email)
+	}
+	verified, err := s.DB.MarkEmailVerified(ctx, database.MarkEmailVerifiedParams{
+		ID:    row.UserID,
+		Email: row.Email,
+	})
+	if err != nil {
+		return err
+	}
+	if verified == 0 {
+		// the email was changed after the token was sent
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+func (s *EmailVerificationService) changeEmail(ctx context.Context, userID uuid.UUID, newEmail string) error {
+	user, err := s.DB.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ChangeUserEmail(ctx, database.ChangeUserEmailParams{
+		ID:    userID,
+		Email: newEmail,
+	})
+	if isUniqueViolation(err) {
+		// someone signed up with the address in the meantime
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	// let the old address know in case the account was taken over
+	return s.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Your fitterBy email was changed",
+		Body: fmt.Sprintf("Hi %s,\n\nthe email of your account was changed to %s. "+
+			"If you didn't do this, reset your password and contact us.\n", user.Name, newEmail),
+	})
+}
+
+// IsVerified reports whether the user confirmed their current email.
+func (s *EmailVerificationService) IsVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	user, err := s.DB.GetUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerifiedAt.Valid, nil
+}
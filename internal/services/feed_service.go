@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	// Feeds rank the newest posts within a window, the ranking decays old
+	// posts anyway.
+	homeFeedWindow    = 14 * 24 * time.Hour
+	exploreFeedWindow = 7 * 24 * time.Hour
+	maxFeedCandidates = 500
+)
+
+// FeedSignals are what a post is ranked by, as seen by the viewer of the
+// feed.
+type FeedSignals struct {
+	CreatedAt time.Time
+	Likes     int64
+	Comments  int64
+	// Following is set for posts of users the viewer follows, FromProgram
+	// for authors of programs the viewer does.
+	Following   bool
+	FromProgram bool
+	// Interactions counts the likes and comments of the viewer on the posts
+	// of the author.
+	Interactions int64
+	// TagAffinity counts the tags of the post the viewer is into.
+	TagAffinity int64
+}
+
+// Ranker scores posts for a feed, higher scores come first. Scores must only
+// depend on the signals and now. Candidates count likes and comments up to
+// the time the feed was ranked, so a post keeps its score across pages unless
+// a like is withdrawn or the viewer's follows and programs change, which can
+// move it past the cursor.
+type Ranker interface {
+	Score(s FeedSignals, now time.Time) float64
+}
+
+// WeightedRanker multiplies the engagement, relationship and tag affinity of
+// a post and halves the result every HalfLife. Counts are log scaled so a
+// few viral posts don't take over the feed.
+type WeightedRanker struct {
+	HalfLife          time.Duration
+	LikeWeight        float64
+	CommentWeight     float64
+	FollowWeight      float64
+	ProgramWeight     float64
+	InteractionWeight float64
+	TagWeight         float64
+	// MaxTagAffinity caps how many matching tags count.
+	MaxTagAffinity int64
+}
+
+func NewWeightedRanker() WeightedRanker {
+	return WeightedRanker{
+		HalfLife:          24 * time.Hour,
+		LikeWeight:        1,
+		CommentWeight:     1.5,
+		FollowWeight:      1,
+		ProgramWeight:     0.5,
+		InteractionWeight: 0.5,
+		TagWeight:         0.5,
+		MaxTagAffinity:    3,
+	}
+}
+
+func (r WeightedRanker) Score(s FeedSignals, now time.Time) float64 {
+	engagement := 1 + r.LikeWeight*math.Log1p(float64(s.Likes)) + r.CommentWeight*math.Log1p(float64(s.Comments))
+	relationship := 1 + r.InteractionWeight*math.Log1p(float64(s.Interactions))
+	if s.Following {
+		relationship += r.FollowWeight
+	}
+	if s.FromProgram {
+		relationship += r.ProgramWeight
+	}
+	affinity := 1 + r.TagWeight*float64(min(s.TagAffinity, r.MaxTagAffinity))
+	age := max(0, now.Sub(s.CreatedAt))
+	return engagement * relationship * affinity * math.Exp2(-age.Hours()/r.HalfLife.Hours())
+}
+
+// FeedPosition is where a page of a ranked feed ends.
+type FeedPosition struct {
+	Score float64
+	ID    uuid.UUID
+}
+
+// FeedPage asks for the Size posts after a position of a feed ranked at At.
+// Posts, likes and comments created after At wait for the feed to be loaded
+// again, so they don't show up in the middle of it or reorder it.
+type FeedPage struct {
+	At    time.Time
+	After *FeedPosition
+	Size  int
+}
+
+// FeedPost is a post of a feed with its score. Explore candidates have the
+// same columns as home feed candidates.
+type FeedPost struct {
+	database.GetHomeFeedCandidatesRow
+	Score float64
+}
+
+// FeedService ranks the home and explore feeds.
+type FeedService struct {
+	DB     *database.Queries
+	Ranker Ranker
+}
+
+func NewFeedService(db *database.Queries, ranker Ranker) *FeedService {
+	return &FeedService{DB: db, Ranker: ranker}
+}
+
+// Home ranks recent posts of the users the viewer follows and of the authors
+// of programs they do.
+func (s *FeedService) Home(ctx context.Context, viewerID uuid.UUID, page FeedPage) ([]FeedPost, *FeedPosition, error) {
+	rows, err := s.DB.GetHomeFeedCandidates(ctx, database.GetHomeFeedCandidatesParams{
+		ViewerID:      viewerID,
+		Since:         page.At.Add(-homeFeedWindow),
+		Until:         page.At,
+		MaxCandidates: maxFeedCandidates,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	posts, next := rankFeed(s.Ranker, rows, page)
+	return posts, next, nil
+}
+
+// Explore ranks recent public posts of users the viewer doesn't follow.
+func (s *FeedService) Explore(ctx context.Context, viewerID uuid.NullUUID, page FeedPage) ([]FeedPost, *FeedPosition, error) {
+	rows, err := s.DB.GetExploreCandidates(ctx, database.GetExploreCandidatesParams{
+		ViewerID:      viewerID,
+		Since:         page.At.Add(-exploreFeedWindow),
+		Until:         page.At,
+		MaxCandidates: maxFeedCandidates,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	candidates := make([]database.GetHomeFeedCandidatesRow, len(rows))
+	for i, row := range rows {
+		candidates[i] = database.GetHomeFeedCandidatesRow(row)
+	}
+	posts, next := rankFeed(s.Ranker, candidates, page)
+	return posts, next, nil
+}
+
+// rankFeed scores the candidates as of page.At, orders them by score and id
+// and returns the page after page.After, with the position of its last post
+// when there are more. A post shared by several plain reposts is ranked once,
+// as the best scoring of them.
+func rankFeed(ranker Ranker, rows []database.GetHomeFeedCandidatesRow, page FeedPage) ([]FeedPost, *FeedPosition) {
+	posts := make([]FeedPost, 0, len(rows))
+	items := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		if row.CreatedAt.After(page.At) {
+			continue
+		}
+		post := FeedPost{GetHomeFeedCandidatesRow: row, Score: ranker.Score(FeedSignals{
+			CreatedAt:    row.CreatedAt,
+			Likes:        row.LikeCount,
+			Comments:     row.CommentsCount,
+			Following:    row.Following,
+			FromProgram:  row.FromProgram,
+			Interactions: row.Interactions,
+			TagAffinity:  row.TagAffinity,
+		}, page.At)}
+		item := row.ID
+		if IsPlainRepost(row.Content, row.RepostOf) {
+			item = row.RepostOf.UUID
+		}
+		if i, ok := items[item]; ok {
+			if rankedBefore(post.Score, post.ID, posts[i].Score, posts[i].ID) {
+				posts[i] = post
+			}
+			continue
+		}
+		items[item] = len(posts)
+		posts = append(posts, post)
+	}
+	if page.After != nil {
+		posts = slices.DeleteFunc(posts, func(p FeedPost) bool {
+			return !rankedBefore(page.After.Score, page.After.ID, p.Score, p.ID)
+		})
+	}
+	slices.SortFunc(posts, func(a, b FeedPost) int {
+		if rankedBefore(a.Score, a.ID, b.Score, b.ID) {
+			return -1
+		}
+		return 1
+	})
+	if len(posts) <= page.Size {
+		return posts, nil
+	}
+	posts = posts[:page.Size]
+	last := posts[len(posts)-1]
+	return posts, &FeedPosition{Score: last.Score, ID: last.ID}
+}
+
+// rankedBefore reports whether a post comes before another one in a feed.
+// Ties are broken by id so the order is total.
+func rankedBefore(score float64, id uuid.UUID, otherScore float64, otherID uuid.UUID) bool {
+	if score != otherScore {
+		return score > otherScore
+	}
+	return strings.Compare(id.String(), otherID.String()) > 0
+}
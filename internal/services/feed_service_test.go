@@ -0,0 +1,128 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+func TestWeightedRanker(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	ranker := NewWeightedRanker()
+	base := FeedSignals{CreatedAt: now.Add(-time.Hour), Likes: 3, Comments: 1}
+	score := ranker.Score(base, now)
+
+	tests := []struct {
+		name    string
+		signals func(s FeedSignals) FeedSignals
+	}{
+		{"newer", func(s FeedSignals) FeedSignals { s.CreatedAt = now; return s }},
+		{"more likes", func(s FeedSignals) FeedSignals { s.Likes = 30; return s }},
+		{"more comments", func(s FeedSignals) FeedSignals { s.Comments = 5; return s }},
+		{"followed author", func(s FeedSignals) FeedSignals { s.Following = true; return s }},
+		{"program author", func(s FeedSignals) FeedSignals { s.FromProgram = true; return s }},
+		{"interactions", func(s FeedSignals) FeedSignals { s.Interactions = 4; return s }},
+		{"tag affinity", func(s FeedSignals) FeedSignals { s.TagAffinity = 2; return s }},
+	}
+	for _, tt := range tests {
+		if got := ranker.Score(tt.signals(base), now); got <= score {
+			t.Errorf("%s: score %v, want more than %v", tt.name, got, score)
+		}
+	}
+
+	dayOld := base
+	dayOld.CreatedAt = base.CreatedAt.Add(-ranker.HalfLife)
+	if got := ranker.Score(dayOld, now); got < score/2-1e-9 || got > score/2+1e-9 {
+		t.Errorf("score after a half life = %v, want %v", got, score/2)
+	}
+	lots := base
+	lots.TagAffinity = 100
+	capped := base
+	capped.TagAffinity = ranker.MaxTagAffinity
+	if ranker.Score(lots, now) != ranker.Score(capped, now) {
+		t.Error("tag affinity isn't capped")
+	}
+}
+
+func TestRankFeed(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	var rows []database.GetHomeFeedCandidatesRow
+	for i := range 7 {
+		rows = append(rows, database.GetHomeFeedCandidatesRow{
+			ID:        uuid.New(),
+			CreatedAt: now.Add(-time.Duration(i) * time.Hour),
+			LikeCount: int64(i % 3),
+		})
+	}
+	// ties with another post, the id breaks them
+	twin := rows[0]
+	twin.ID = uuid.New()
+	rows = append(rows, twin)
+	// created after the feed was ranked
+	rows = append(rows, database.GetHomeFeedCandidatesRow{ID: uuid.New(), CreatedAt: now.Add(time.Minute)})
+
+	ranker := NewWeightedRanker()
+	seen := map[uuid.UUID]bool{}
+	page := FeedPage{At: now, Size: 3}
+	var last *FeedPost
+	for pages := 0; ; pages++ {
+		if pages > 5 {
+			t.Fatal("paging doesn't end")
+		}
+		posts, next := rankFeed(ranker, rows, page)
+		for i := range posts {
+			p := posts[i]
+			if seen[p.ID] {
+				t.Fatalf("post %v shows up twice", p.ID)
+			}
+			seen[p.ID] = true
+			if last != nil && !rankedBefore(last.Score, last.ID, p.Score, p.ID) {
+				t.Errorf("post %v (%v) comes after %v (%v)", p.ID, p.Score, last.ID, last.Score)
+			}
+			last = &p
+		}
+		if next == nil {
+			break
+		}
+		if len(posts) != page.Size {
+			t.Errorf("page has %d posts, want %d", len(posts), page.Size)
+		}
+		page.After = next
+	}
+	if len(seen) != 8 {
+		t.Errorf("got %d posts, want 8 without the one created after ranking", len(seen))
+	}
+}
+
+func TestRankFeedReposts(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	original := database.GetHomeFeedCandidatesRow{ID: uuid.New(), CreatedAt: now.Add(-5 * time.Hour)}
+	repost := func(content string, age time.Duration) database.GetHomeFeedCandidatesRow {
+		return database.GetHomeFeedCandidatesRow{
+			ID:        uuid.New(),
+			CreatedAt: now.Add(-age),
+			Content:   content,
+			RepostOf:  uuid.NullUUID{UUID: original.ID, Valid: true},
+		}
+	}
+	newest := repost("", time.Hour)
+	quote := repost("so true", 2*time.Hour)
+	rows := []database.GetHomeFeedCandidatesRow{original, repost("", 3*time.Hour), newest, quote}
+
+	posts, next := rankFeed(NewWeightedRanker(), rows, FeedPage{At: now, Size: 10})
+	if next != nil {
+		t.Error("got a next page")
+	}
+	var ids []uuid.UUID
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	// the original and its plain reposts show up once, as the best ranked of
+	// them, quotes are posts of their own
+	want := []uuid.UUID{newest.ID, quote.ID}
+	if len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] {
+		t.Errorf("got %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	FollowPending  = "pending"
+	FollowAccepted = "accepted"
+)
+
+var (
+	ErrUserNotFound          = errors.New("user not found")
+	ErrCannotFollowSelf      = errors.New("users can't follow themselves")
+	ErrNotFollowing          = errors.New("not following")
+	ErrFollowRequestNotFound = errors.New("follow request not found")
+)
+
+type FollowService struct {
+	DB       *database.Queries
+	Profiles *ProfileService
+}
+
+func NewFollowService(db *database.Queries, profiles *ProfileService) *FollowService {
+	return &FollowService{DB: db, Profiles: profiles}
+}
+
+// Follow follows the user right away, or asks for approval when their
+// account is private. Following again returns the existing status.
+func (s *FollowService) Follow(ctx context.Context, followerID, followedID uuid.UUID) (string, error) {
+	if followerID == followedID {
+		return "", ErrCannotFollowSelf
+	}
+	followed, err := s.DB.GetUser(ctx, followedID)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && followed.DeletionScheduledAt.Valid {
+		return "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	blocked, err := s.DB.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+		BlockerID: followerID,
+		BlockedID: followedID,
+	})
+	if err != nil {
+		return "", err
+	}
+	if blocked {
+		return "", ErrBlocked
+	}
+	settings, err := s.Profiles.Settings(ctx, followedID)
+	if err != nil {
+		return "", err
+	}
+	status := FollowAccepted
+	if settings.ProfileVisibility == VisibilityPrivate {
+		status = FollowPending
+	}
+	created, err := s.DB.FollowUser(ctx, database.FollowUserParams{
+		FollowerID: followerID,
+		FollowedID: followedID,
+		Status:     status,
+	})
+	if err != nil {
+		return "", err
+	}
+	if created == 0 {
+		return s.DB.GetFollowStatus(ctx, database.GetFollowStatusParams{
+			FollowerID: followerID,
+			FollowedID: followedID,
+		})
+	}
+	return status, nil
+}
+
+// Unfollow also withdraws a pending follow request.
+func (s *FollowService) Unfollow(ctx context.Context, followerID, followedID uuid.UUID) error {
+	deleted, err := s.DB.UnfollowUser(ctx, database.UnfollowUserParams{
+		FollowerID: followerID,
+		FollowedID: followedID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrNotFollowing
+	}
+	return nil
+}
+
+func (s *FollowService) Approve(ctx context.Context, userID, followerID uuid.UUID) error {
+	updated, err := s.DB.AcceptFollowRequest(ctx, database.AcceptFollowRequestParams{
+		FollowerID: followerID,
+		FollowedID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrFollowRequestNotFound
+	}
+	return nil
+}
+
+func (s *FollowService) Reject(ctx context.Context, userID, followerID uuid.UUID) error {
+	deleted, err := s.DB.RejectFollowRequest(ctx, database.RejectFollowRequestParams{
+		FollowerID: followerID,
+		FollowedID: userID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrFollowRequestNotFound
+	}
+	return nil
+}
+
+// IsFollowing reports whether follower is an accepted follower of followed.
+func (s *FollowService) IsFollowing(ctx context.Context, followerID, followedID uuid.UUID) (bool, error) {
+	status, err := s.DB.GetFollowStatus(ctx, database.GetFollowStatusParams{
+		FollowerID: followerID,
+		FollowedID: followedID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return status == FollowAccepted, nil
+}
+
+// CanViewConnections reports whether viewer may see who the owner follows
+// and is followed by. Only public profiles show them to everyone.
+func (s *FollowService) CanViewConnections(ctx context.Context, viewerID, ownerID uuid.UUID) (bool, error) {
+	if viewerID == ownerID {
+		return true, nil
+	}
+	settings, err := s.Profiles.Settings(ctx, ownerID)
+	if err != nil {
+		return false, err
+	}
+	if settings.ProfileVisibility == VisibilityPublic {
+		return true, nil
+	}
+	return s.IsFollowing(ctx, viewerID, ownerID)
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	// maxImagePixels keeps small files that decode to huge images out.
+	maxImagePixels = 40_000_000
+	thumbnailSize  = 320
+)
+
+// processedImage is an upload re-encoded without its metadata.
+type processedImage struct {
+	ContentType string
+	Ext         string
+	Data        []byte
+	Thumbnail   []byte
+	Width       int
+	Height      int
+}
+
+// processImage decodes a JPEG or PNG image and encodes it again, which drops
+// EXIF and every other metadata. JPEGs are turned upright first, the
+// orientation is part of the EXIF data that is dropped.
+func processImage(data []byte, contentType string) (processedImage, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return processedImage{}, ErrUnsupportedMedia
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return processedImage{}, ErrImageTooLarge
+	}
+	var out bytes.Buffer
+	var img image.Image
+	result := processedImage{ContentType: contentType}
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return processedImage{}, ErrUnsupportedMedia
+		}
+		img = orient(img, jpegOrientation(data))
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90})
+		result.Ext = ".jpg"
+	case "png":
+		img, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return processedImage{}, ErrUnsupportedMedia
+		}
+		err = png.Encode(&out, img)
+		result.Ext = ".png"
+	default:
+		return processedImage{}, ErrUnsupportedMedia
+	}
+	if err != nil {
+		return processedImage{}, err
+	}
+	result.Data = out.Bytes()
+	result.Width, result.Height = img.Bounds().Dx(), img.Bounds().Dy()
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, thumbnail(img, thumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return processedImage{}, err
+	}
+	result.Thumbnail = thumb.Bytes()
+	return result, nil
+}
+
+// thumbnail scales img down to fit into a size by size square, on a white
+// background for transparent images. Every pixel averages a grid of at most
+// 4x4 samples of the area it covers, which is plenty for a preview and fast
+// for large images.
+func thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+(y+1)*b.Dy()/h
+		for x := 0; x < w; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+(x+1)*b.Dx()/w
+			dst.Set(x, y, average(img, x0, max(x1, x0+1), y0, max(y1, y0+1)))
+		}
+	}
+	out := image.NewRGBA(dst.Bounds())
+	draw.Draw(out, out.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), dst, image.Point{}, draw.Over)
+	return out
+}
+
+func average(img image.Image, x0, x1, y0, y1 int) color.Color {
+	stepX, stepY := max(1, (x1-x0)/4), max(1, (y1-y0)/4)
+	var r, g, b, a, n uint32
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			cr, cg, cb, ca := img.At(x, y).RGBA()
+			r, g, b, a, n = r+cr, g+cg, b+cb, a+ca, n+1
+		}
+	}
+	return color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)}
+}
+
+// jpegOrientation reads the EXIF orientation of a JPEG, 1 when it has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data) && data[i] == 0xFF; {
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag of the first IFD of TIFF data.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		// 0x0112 is the orientation, stored as a SHORT
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+		}
+	}
+	return 1
+}
+
+// orient turns an image with the given EXIF orientation upright.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			dst.Set(x, y, img.At(b.Min.X+sx, b.Min.Y+sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+// exifSegment builds an APP1 segment with a big endian IFD holding the
+// orientation and a string standing in for GPS and camera data.
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	binary.Write(&tiff, binary.BigEndian, uint16(42))
+	binary.Write(&tiff, binary.BigEndian, uint32(8))
+	binary.Write(&tiff, binary.BigEndian, uint16(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(&tiff, binary.BigEndian, uint32(1))
+	binary.Write(&tiff, binary.BigEndian, []uint16{orientation, 0})
+	binary.Write(&tiff, binary.BigEndian, uint32(0))
+	tiff.WriteString("secret location")
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append([]byte{0xFF, 0xD8}, exifSegment(orientation)...), data[2:]...)
+}
+
+func TestProcessImageStripsExif(t *testing.T) {
+	data := testJPEG(t, 40, 20, 6)
+	if got := jpegOrientation(data); got != 6 {
+		t.Fatalf("orientation = %d, want 6", got)
+	}
+
+	img, err := processImage(data, http.DetectContentType(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(img.Data, []byte("Exif")) || bytes.Contains(img.Data, []byte("secret location")) {
+		t.Error("EXIF data was kept")
+	}
+	if img.Width != 20 || img.Height != 40 {
+		t.Errorf("size = %dx%d, want the image turned upright to 20x40", img.Width, img.Height)
+	}
+	if img.ContentType != "image/jpeg" || img.Ext != ".jpg" {
+		t.Errorf("got %s %s", img.ContentType, img.Ext)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(img.Thumbnail)); err != nil {
+		t.Errorf("thumbnail isn't a jpeg: %v", err)
+	}
+}
+
+func TestProcessImageRejects(t *testing.T) {
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 4, 4)))
+
+	// a tiny png that claims to be 100000x100000 pixels
+	huge := bytes.Clone(pngData.Bytes())
+	binary.BigEndian.PutUint32(huge[16:], 100000)
+	binary.BigEndian.PutUint32(huge[20:], 100000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		want        error
+	}{
+		{"text", []byte("<html><script>alert(1)</script></html>"), "text/html; charset=utf-8", ErrUnsupportedMedia},
+		{"png sent as jpeg", pngData.Bytes(), "image/jpeg", ErrUnsupportedMedia},
+		{"truncated jpeg", testJPEG(t, 10, 10, 1)[:40], "image/jpeg", ErrUnsupportedMedia},
+		{"decompression bomb", huge, "image/png", ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		if _, err := processImage(tt.data, tt.contentType); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestOrient(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	tests := []struct {
+		orientation int
+		size        image.Point
+		first       color.RGBA
+	}{
+		{1, image.Pt(2, 1), red},
+		{2, image.Pt(2, 1), blue},
+		{3, image.Pt(2, 1), blue},
+		{6, image.Pt(1, 2), red},
+		{8, image.Pt(1, 2), blue},
+	}
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+		if got.Bounds().Size() != tt.size {
+			t.Errorf("orientation %d: size = %v, want %v", tt.orientation, got.Bounds().Size(), tt.size)
+			continue
+		}
+		if c := color.RGBAModel.Convert(got.At(0, 0)); c != tt.first {
+			t.Errorf("orientation %d: first pixel = %v, want %v", tt.orientation, c, tt.first)
+		}
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	tests := []struct {
+		w, h int
+		want image.Point
+	}{
+		{1000, 500, image.Pt(320, 160)},
+		{500, 1000, image.Pt(160, 320)},
+		{100, 50, image.Pt(100, 50)},
+		{5000, 1, image.Pt(320, 1)},
+	}
+	for _, tt := range tests {
+		got := thumbnail(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), thumbnailSize).Bounds().Size()
+		if got != tt.want {
+			t.Errorf("thumbnail of %dx%d = %v, want %v", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
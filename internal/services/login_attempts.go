@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+// LoginAttempts are the failed logins counted for one account or IP address.
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// LoginAttemptStore keeps failed login counters by key.
+type LoginAttemptStore interface {
+	// Get returns the zero value for keys without failures.
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+	RecordFailure(ctx context.Context, key string, at time.Time) (LoginAttempts, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+type PostgresLoginAttemptStore struct {
+	DB *database.Queries
+}
+
+func NewPostgresLoginAttemptStore(db *database.Queries) *PostgresLoginAttemptStore {
+	return &PostgresLoginAttemptStore{DB: db}
+}
+
+func (s *PostgresLoginAttemptStore) Get(ctx context.Context, key string) (LoginAttempts, error) {
+	attempts, err := s.DB.GetLoginAttempts(ctx, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return LoginAttempts{}, nil
+	}
+	if err != nil {
+		return LoginAttempts{}, err
+	}
+	return loginAttemptsFromRow(attempts), nil
+}
+
+func (s *PostgresLoginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time) (LoginAttempts, error) {
+	attempts, err := s.DB.RecordLoginFailure(ctx, database.RecordLoginFailureParams{
+		AttemptKey:    key,
+		LastFailureAt: at,
+	})
+	if err != nil {
+		return LoginAttempts{}, err
+	}
+	return loginAttemptsFromRow(attempts), nil
+}
+
+func (s *PostgresLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	return s.DB.LockLoginAttempts(ctx, database.LockLoginAttemptsParams{
+		AttemptKey:  key,
+		LockedUntil: sql.NullTime{Time: until, Valid: true},
+	})
+}
+
+func (s *PostgresLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.DB.DeleteLoginAttempts(ctx, key)
+}
+
+func loginAttemptsFromRow(row database.LoginAttempt) LoginAttempts {
+	return LoginAttempts{
+		Failures:      int(row.Failures),
+		LastFailureAt: row.LastFailureAt,
+		LockedUntil:   row.LockedUntil.Time,
+	}
+}
+
+// MemoryLoginAttemptStore keeps counters in process memory. Counters are lost
+// on restart and not shared between instances.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]LoginAttempts
+}
+
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{attempts: make(map[string]LoginAttempts)}
+}
+
+func (s *MemoryLoginAttemptStore) Get(ctx context.Context, key string) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key], nil
+}
+
+func (s *MemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, at time.Time) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts := s.attempts[key]
+	attempts.Failures++
+	attempts.LastFailureAt = at
+	s.attempts[key] = attempts
+	return attempts, nil
+}
+
+func (s *MemoryLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempts := s.attempts[key]
+	attempts.LockedUntil = until
+	s.attempts[key] = attempts
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	EventAccountLocked = "account_locked"
+	EventIPLocked      = "ip_locked"
+)
+
+var (
+	ErrLoginThrottled = errors.New("too many failed logins, retry later")
+	ErrLoginLocked    = errors.New("login temporarily locked")
+)
+
+type AuthEventRecorder interface {
+	CreateAuthEvent(ctx context.Context, arg database.CreateAuthEventParams) error
+}
+
+// LoginGuard slows down and finally locks logins after repeated failures,
+// counted both per account and per IP address.
+type LoginGuard struct {
+	Store  LoginAttemptStore
+	Events AuthEventRecorder
+	// FreeAttempts failures are allowed before the backoff starts, it then
+	// doubles from BaseDelay with every failure up to MaxDelay.
+	FreeAttempts int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	// an account or IP is locked for LockoutDuration once it reaches its threshold
+	AccountLockoutThreshold int
+	IPLockoutThreshold      int
+	LockoutDuration         time.Duration
+	// failures older than ResetAfter are forgotten
+	ResetAfter time.Duration
+	Now        func() time.Time
+
+	locks keyLocks
+}
+
+func NewLoginGuard(store LoginAttemptStore, events AuthEventRecorder) *LoginGuard {
+	return &LoginGuard{
+		Store:                   store,
+		Events:                  events,
+		FreeAttempts:            3,
+		BaseDelay:               time.Second,
+		MaxDelay:                5 * time.Minute,
+		AccountLockoutThreshold: 10,
+		IPLockoutThreshold:      50,
+		LockoutDuration:         15 * time.Minute,
+		ResetAfter:              time.Hour,
+		Now:                     time.Now,
+	}
+}
+
+// Check returns ErrLoginThrottled or ErrLoginLocked with the time to wait when
+// the account or IP may not try to log in right now.
+func (g *LoginGuard) Check(ctx context.Context, email, ip string) (time.Duration, error) {
+	return g.check(ctx, accountKey(email), ipKey(ip))
+}
+
+// Begin is Check for a login about to be tried. Until release is called the
+// account and IP are held, so concurrent logins wait for the failure of the
+// previous one to be recorded instead of all passing the check at once.
+// Logins are only held within one instance. Callers defer release, it does
+// nothing when Begin returns an error.
+func (g *LoginGuard) Begin(ctx context.Context, email, ip string) (release func(), wait time.Duration, err error) {
+	unlockAccount := g.locks.lock(accountKey(email))
+	unlockIP := g.locks.lock(ipKey(ip))
+	release = func() {
+		unlockIP()
+		unlockAccount()
+	}
+	wait, err = g.Check(ctx, email, ip)
+	if err != nil {
+		release()
+		return func() {}, wait, err
+	}
+	return release, 0, nil
+}
+
+// AllowMail counts a reset or verification email asked for the address from
+// ip and returns ErrLoginThrottled when it asks too often, with the same
+// backoff as failed logins. Mails are counted apart from logins, so asking
+// for them can't lock anyone out.
+func (g *LoginGuard) AllowMail(ctx context.Context, email, ip string) (time.Duration, error) {
+	keys := []string{mailKey(accountKey(email)), mailKey(ipKey(ip))}
+	for _, key := range keys {
+		defer g.locks.lock(key)()
+	}
+	if wait, err := g.check(ctx, keys...); err != nil {
+		return wait, err
+	}
+	for _, key := range keys {
+		if _, err := g.Store.RecordFailure(ctx, key, g.Now()); err != nil {
+			return 0, err
+		}
+	}
+	return 0, nil
+}
+
+func (g *LoginGuard) check(ctx context.Context, keys ...string) (time.Duration, error) {
+	var lockedFor, throttledFor time.Duration
+	for _, key := range keys {
+		attempts, err := g.current(ctx, key)
+		if err != nil {
+			return 0, err
+		}
+		now := g.Now()
+		if now.Before(attempts.LockedUntil) {
+			lockedFor = max(lockedFor, attempts.LockedUntil.Sub(now))
+			continue
+		}
+		if next := attempts.LastFailureAt.Add(g.backoff(attempts.Failures)); now.Before(next) {
+			throttledFor = max(throttledFor, next.Sub(now))
+		}
+	}
+	if lockedFor > 0 {
+		return lockedFor, ErrLoginLocked
+	}
+	if throttledFor > 0 {
+		return throttledFor, ErrLoginThrottled
+	}
+	return 0, nil
+}
+
+// RecordFailure counts a failed login and locks the account or IP when it
+// reaches its threshold. userID is only known when the email exists.
+func (g *LoginGuard) RecordFailure(ctx context.Context, email, ip string, userID uuid.NullUUID) error {
+	if err := g.recordFailure(ctx, accountKey(email), g.AccountLockoutThreshold, EventAccountLocked, userID, ip); err != nil {
+		return err
+	}
+	return g.recordFailure(ctx, ipKey(ip), g.IPLockoutThreshold, EventIPLocked, uuid.NullUUID{}, ip)
+}
+
+// RecordSuccess clears the failures of the account. The IP counter is kept so
+// a valid login to one account doesn't help guessing another.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, email string) error {
+	return g.Store.Reset(ctx, accountKey(email))
+}
+
+func (g *LoginGuard) recordFailure(ctx context.Context, key string, threshold int, event string, userID uuid.NullUUID, ip string) error {
+	if _, err := g.current(ctx, key); err != nil {
+		return err
+	}
+	now := g.Now()
+	attempts, err := g.Store.RecordFailure(ctx, key, now)
+	if err != nil {
+		return err
+	}
+	if attempts.Failures < threshold || now.Before(attempts.LockedUntil) {
+		return nil
+	}
+	lockedUntil := now.Add(g.LockoutDuration)
+	if err := g.Store.Lock(ctx, key, lockedUntil); err != nil {
+		return err
+	}
+	return g.Events.CreateAuthEvent(ctx, database.CreateAuthEventParams{
+		UserID:    userID,
+		EventType: event,
+		Details:   fmt.Sprintf("%s locked until %s after %d failed logins", key, lockedUntil.Format(time.RFC3339), attempts.Failures),
+		IpAddress: ip,
+	})
+}
+
+// current returns the attempts of key, forgetting them when the lockout is
+// over or the last failure is old enough.
+func (g *LoginGuard) current(ctx context.Context, key string) (LoginAttempts, error) {
+	attempts, err := g.Store.Get(ctx, key)
+	if err != nil {
+		return LoginAttempts{}, err
+	}
+	if attempts.Failures == 0 {
+		return attempts, nil
+	}
+	now := g.Now()
+	lockExpired := !attempts.LockedUntil.IsZero() && !now.Before(attempts.LockedUntil)
+	if lockExpired || now.Sub(attempts.LastFailureAt) > g.ResetAfter {
+		if err := g.Store.Reset(ctx, key); err != nil {
+			return LoginAttempts{}, err
+		}
+		return LoginAttempts{}, nil
+	}
+	return attempts, nil
+}
+
+func (g *LoginGuard) backoff(failures int) time.Duration {
+	if failures <= g.FreeAttempts {
+		return 0
+	}
+	delay := g.BaseDelay
+	for i := g.FreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= g.MaxDelay {
+			return g.MaxDelay
+		}
+	}
+	return delay
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func mailKey(key string) string {
+	return "mail:" + key
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// keyLocks hands out a mutex per key and forgets it once nobody holds it.
+// Account keys are always locked before IP keys, so holders of both can't
+// deadlock.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+type keyLock struct {
+	sync.Mutex
+	holders int
+}
+
+func (l *keyLocks) lock(key string) (unlock func()) {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	k, ok := l.locks[key]
+	if !ok {
+		k = &keyLock{}
+		l.locks[key] = k
+	}
+	k.holders++
+	l.mu.Unlock()
+
+	k.Lock()
+	return func() {
+		k.Unlock()
+		l.mu.Lock()
+		k.holders--
+		if k.holders == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
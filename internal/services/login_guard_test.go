@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedEvents struct {
+	events []database.CreateAuthEventParams
+}
+
+func (r *recordedEvents) CreateAuthEvent(ctx context.Context, arg database.CreateAuthEventParams) error {
+	r.events = append(r.events, arg)
+	return nil
+}
+
+func newTestLoginGuard() (*LoginGuard, *recordedEvents, *time.Time) {
+	events := &recordedEvents{}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard := NewLoginGuard(NewMemoryLoginAttemptStore(), events)
+	guard.Now = func() time.Time { return now }
+	return guard, events, &now
+}
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+
+	t.Run("should allow free attempts without delay", func(t *testing.T) {
+		guard, _, _ := newTestLoginGuard()
+
+		for range guard.FreeAttempts {
+			require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		}
+		wait, err := guard.Check(ctx, "user@fitterby.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("should back off exponentially", func(t *testing.T) {
+		guard, _, now := newTestLoginGuard()
+
+		for range guard.FreeAttempts + 1 {
+			require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		}
+		wait, err := guard.Check(ctx, "user@fitterby.com", "10.0.0.1")
+		assert.ErrorIs(t, err, ErrLoginThrottled)
+		assert.Equal(t, guard.BaseDelay, wait)
+
+		require.NoError(t, guard.RecordFailure(ctx, "User@Fitterby.com", "10.0.0.2", userID))
+		wait, err = guard.Check(ctx, "user@fitterby.com", "10.0.0.3")
+		assert.ErrorIs(t, err, ErrLoginThrottled)
+		assert.Equal(t, 2*guard.BaseDelay, wait)
+
+		*now = now.Add(2 * guard.BaseDelay)
+		_, err = guard.Check(ctx, "user@fitterby.com", "10.0.0.3")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should lock account and record the event", func(t *testing.T) {
+		guard, events, now := newTestLoginGuard()
+
+		for range guard.AccountLockoutThreshold {
+			require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		}
+		wait, err := guard.Check(ctx, "user@fitterby.com", "10.0.0.9")
+
+		assert.ErrorIs(t, err, ErrLoginLocked)
+		assert.Equal(t, guard.LockoutDuration, wait)
+		require.Len(t, events.events, 1)
+		assert.Equal(t, EventAccountLocked, events.events[0].EventType)
+		assert.Equal(t, userID, events.events[0].UserID)
+		assert.Equal(t, "10.0.0.1", events.events[0].IpAddress)
+
+		*now = now.Add(guard.LockoutDuration)
+		wait, err = guard.Check(ctx, "user@fitterby.com", "10.0.0.9")
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("should lock IP across accounts", func(t *testing.T) {
+		guard, events, _ := newTestLoginGuard()
+		guard.IPLockoutThreshold = 5
+
+		for i := range guard.IPLockoutThreshold {
+			require.NoError(t, guard.RecordFailure(ctx, string(rune('a'+i))+"@fitterby.com", "10.0.0.1", uuid.NullUUID{}))
+		}
+		_, err := guard.Check(ctx, "new@fitterby.com", "10.0.0.1")
+
+		assert.ErrorIs(t, err, ErrLoginLocked)
+		require.Len(t, events.events, 1)
+		assert.Equal(t, EventIPLocked, events.events[0].EventType)
+
+		_, err = guard.Check(ctx, "new@fitterby.com", "10.0.0.2")
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reset account on success", func(t *testing.T) {
+		guard, _, _ := newTestLoginGuard()
+
+		for range guard.FreeAttempts + 2 {
+			require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		}
+		require.NoError(t, guard.RecordSuccess(ctx, "user@fitterby.com"))
+		_, err := guard.Check(ctx, "user@fitterby.com", "10.0.0.2")
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should forget old failures", func(t *testing.T) {
+		guard, _, now := newTestLoginGuard()
+
+		for range guard.FreeAttempts + 5 {
+			require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		}
+		*now = now.Add(guard.ResetAfter + time.Second)
+		require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		wait, err := guard.Check(ctx, "user@fitterby.com", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("should hold concurrent logins until the failure is recorded", func(t *testing.T) {
+		guard, _, _ := newTestLoginGuard()
+		guard.FreeAttempts = 0
+
+		release, _, err := guard.Begin(ctx, "user@fitterby.com", "10.0.0.1")
+		require.NoError(t, err)
+		second := make(chan error, 1)
+		go func() {
+			release, _, err := guard.Begin(ctx, "user@fitterby.com", "10.0.0.2")
+			defer release()
+			second <- err
+		}()
+		select {
+		case <-second:
+			t.Fatal("second login passed the check while the first was running")
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.NoError(t, guard.RecordFailure(ctx, "user@fitterby.com", "10.0.0.1", userID))
+		release()
+
+		assert.ErrorIs(t, <-second, ErrLoginThrottled)
+	})
+
+	t.Run("should throttle mails without touching logins", func(t *testing.T) {
+		guard, _, now := newTestLoginGuard()
+
+		for range guard.FreeAttempts + 1 {
+			_, err := guard.AllowMail(ctx, "user@fitterby.com", "10.0.0.1")
+			require.NoError(t, err)
+		}
+		wait, err := guard.AllowMail(ctx, "user@fitterby.com", "10.0.0.2")
+		assert.ErrorIs(t, err, ErrLoginThrottled)
+		assert.Equal(t, guard.BaseDelay, wait)
+
+		_, err = guard.Check(ctx, "user@fitterby.com", "10.0.0.1")
+		assert.NoError(t, err)
+
+		*now = now.Add(guard.BaseDelay)
+		_, err = guard.AllowMail(ctx, "user@fitterby.com", "10.0.0.2")
+		assert.NoError(t, err)
+	})
+}
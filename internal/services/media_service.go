@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/storage"
+)
+
+const (
+	DefaultMaxUploadBytes = 10 << 20
+	// maxAttachedMedia caps how many images a post, exercise or program has.
+	maxAttachedMedia = 10
+	// Uploads get a day to be attached before the cleanup removes them,
+	// cleanupBatchSize at a time.
+	unattachedMediaMaxAge = 24 * time.Hour
+	cleanupBatchSize      = 100
+)
+
+var (
+	ErrUnsupportedMedia = errors.New("only jpeg and png images can be uploaded")
+	ErrMediaTooLarge    = errors.New("file is too large")
+	ErrImageTooLarge    = errors.New("image has too many pixels")
+	ErrMediaNotFound    = errors.New("media not found")
+	ErrTooManyMedia     = errors.New("too many media files")
+)
+
+// MediaService stores uploaded images. Uploads are sniffed instead of
+// trusting their content type, and stored re-encoded without metadata
+// together with a JPEG thumbnail.
+type MediaService struct {
+	DB       *database.Queries
+	Storage  storage.Storage
+	MaxBytes int64
+}
+
+func NewMediaService(db *database.Queries, store storage.Storage, maxBytes int64) *MediaService {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxUploadBytes
+	}
+	return &MediaService{DB: db, Storage: store, MaxBytes: maxBytes}
+}
+
+func (s *MediaService) Upload(ctx context.Context, userID uuid.UUID, r io.Reader) (database.MediaFile, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.MaxBytes+1))
+	if err != nil {
+		return database.MediaFile{}, err
+	}
+	if int64(len(data)) > s.MaxBytes {
+		return database.MediaFile{}, ErrMediaTooLarge
+	}
+	img, err := processImage(data, http.DetectContentType(data))
+	if err != nil {
+		return database.MediaFile{}, err
+	}
+
+	id := uuid.New()
+	key := id.String() + img.Ext
+	thumbnailKey := id.String() + "_thumb.jpg"
+	if err := s.Storage.Put(ctx, key, img.Data, img.ContentType); err != nil {
+		return database.MediaFile{}, err
+	}
+	if err := s.Storage.Put(ctx, thumbnailKey, img.Thumbnail, "image/jpeg"); err != nil {
+		s.remove(key)
+		return database.MediaFile{}, err
+	}
+	media, err := s.DB.CreateMediaFile(ctx, database.CreateMediaFileParams{
+		ID:           id,
+		UserID:       userID,
+		StorageKey:   key,
+		ThumbnailKey: thumbnailKey,
+		ContentType:  img.ContentType,
+		SizeBytes:    int64(len(img.Data)),
+		Width:        int32(img.Width),
+		Height:       int32(img.Height),
+	})
+	if err != nil {
+		s.remove(key, thumbnailKey)
+		return database.MediaFile{}, err
+	}
+	return media, nil
+}
+
+// URLs returns the URLs of the media files, in the order of ids, and marks
+// them attached so the cleanup keeps them. Every file must have been uploaded
+// by the user, media of other users is reported as not found. Files stay
+// attached when the caller fails to save what uses them.
+func (s *MediaService) URLs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if len(ids) > maxAttachedMedia {
+		return nil, ErrTooManyMedia
+	}
+	files, err := s.DB.AttachUserMediaFiles(ctx, database.AttachUserMediaFilesParams{
+		UserID: userID,
+		Ids:    ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[uuid.UUID]string, len(files))
+	for _, f := range files {
+		keys[f.ID] = f.StorageKey
+	}
+	urls := make([]string, 0, len(ids))
+	for _, id := range ids {
+		key, ok := keys[id]
+		if !ok {
+			return nil, ErrMediaNotFound
+		}
+		urls = append(urls, s.Storage.URL(key))
+	}
+	return urls, nil
+}
+
+// DeleteFiles removes the stored images and thumbnails of files. Their rows
+// are left to the caller.
+func (s *MediaService) DeleteFiles(ctx context.Context, files []database.MediaFile) error {
+	for _, f := range files {
+		if err := s.Storage.Delete(ctx, f.StorageKey); err != nil {
+			return err
+		}
+		if err := s.Storage.Delete(ctx, f.ThumbnailKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CleanupUnattached deletes uploads older than unattachedMediaMaxAge that
+// were never passed to URLs, and returns how many were deleted.
+func (s *MediaService) CleanupUnattached(ctx context.Context) (int, error) {
+	files, err := s.DB.GetUnattachedMediaFiles(ctx, database.GetUnattachedMediaFilesParams{
+		Before:   time.Now().Add(-unattachedMediaMaxAge),
+		MaxFiles: cleanupBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, f := range files {
+		if err := s.DeleteFiles(ctx, []database.MediaFile{f}); err != nil {
+			log.Printf("failed to delete unattached media %s: %v", f.ID, err)
+			continue
+		}
+		if err := s.DB.DeleteMediaFile(ctx, f.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// RunCleanup deletes unattached uploads every interval until ctx is done.
+func (s *MediaService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.CleanupUnattached(ctx)
+		if err != nil {
+			log.Printf("failed to clean up unattached media: %v", err)
+		} else if deleted > 0 {
+			log.Printf("deleted %d unattached media files", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// remove cleans up the files of a failed upload.
+func (s *MediaService) remove(keys ...string) {
+	for _, key := range keys {
+		if err := s.Storage.Delete(context.Background(), key); err != nil {
+			log.Printf("failed to remove %s: %v", key, err)
+		}
+	}
+}
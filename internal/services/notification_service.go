@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	NotificationMention = "mention"
+
+	// maxMentions caps how many users one comment can notify.
+	maxMentions = 10
+)
+
+// mentionPattern matches @name at the start of the text or after a character
+// that can't be part of a name, so emails aren't taken for mentions.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_]+(?:[.-][\p{L}\p{N}_]+)*)`)
+
+type NotificationService struct {
+	DB         *database.Queries
+	Visibility *VisibilityService
+}
+
+func NewNotificationService(db *database.Queries, visibility *VisibilityService) *NotificationService {
+	return &NotificationService{DB: db, Visibility: visibility}
+}
+
+// NotifyMentions notifies the users mentioned with @name in a comment. Names
+// aren't unique, a name shared by several users notifies none of them. Users
+// who can't see the post or are blocked by the commenter aren't notified.
+func (s *NotificationService) NotifyMentions(ctx context.Context, post database.GetPostRow, comment database.PostsComment) error {
+	names := parseMentions(comment.Content)
+	if len(names) == 0 {
+		return nil
+	}
+	users, err := s.DB.GetUsersByNames(ctx, names)
+	if err != nil {
+		return err
+	}
+	matches := make(map[string][]uuid.UUID)
+	for _, u := range users {
+		matches[u.Name] = append(matches[u.Name], u.ID)
+	}
+	for _, name := range names {
+		ids := matches[name]
+		if len(ids) != 1 || ids[0] == comment.UserID.UUID {
+			continue
+		}
+		mentioned := uuid.NullUUID{UUID: ids[0], Valid: true}
+		allowed, err := s.Visibility.CanViewPost(ctx, mentioned, post.AuthorID.UUID, post.Visibility)
+		if err != nil {
+			return err
+		}
+		blocked, err := s.Visibility.Blocks.IsBlocked(ctx, mentioned, comment.UserID.UUID)
+		if err != nil {
+			return err
+		}
+		if !allowed || blocked {
+			continue
+		}
+		err = s.DB.CreateNotification(ctx, database.CreateNotificationParams{
+			UserID:    mentioned.UUID,
+			ActorID:   comment.UserID.UUID,
+			Type:      NotificationMention,
+			PostID:    uuid.NullUUID{UUID: comment.PostID, Valid: true},
+			CommentID: uuid.NullUUID{UUID: comment.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMentions returns the lower cased names mentioned in text, each once
+// and at most maxMentions of them.
+func parseMentions(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(match[1])
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxMentions {
+			break
+		}
+	}
+	return names
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"no mentions here", nil},
+		{"@Bob nice lift", []string{"bob"}},
+		{"thanks @anna and @bob.", []string{"anna", "bob"}},
+		{"@anna @Anna @ANNA", []string{"anna"}},
+		{"(@jan_k), @li.wei!", []string{"jan_k", "li.wei"}},
+		{"mail me at coach@example.com", nil},
+		{"@@bob @", nil},
+		{"@zoë", []string{"zoë"}},
+	}
+	for _, tt := range tests {
+		if got := parseMentions(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("parseMentions(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	many := ""
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		many += " @" + name
+	}
+	if got := parseMentions(many); len(got) != maxMentions {
+		t.Errorf("got %d mentions, want them capped at %d", len(got), maxMentions)
+	}
+}
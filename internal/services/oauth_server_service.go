@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/oauth"
+)
+
+const oauthClientIDPrefix = "fbc_"
+
+var (
+	ErrOAuthClientNotFound = errors.New("oauth client not found")
+	ErrInvalidRedirectURI  = errors.New("redirect uri not registered")
+	ErrOAuthGrantNotFound  = errors.New("app not authorized")
+)
+
+// OAuthError is reported to third-party apps with one of the error codes of
+// RFC 6749.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) error {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// AuthorizationRequest holds the query parameters of an authorization request.
+type AuthorizationRequest struct {
+	ResponseType        string `json:"response_type"`
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+type ClientTokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int64
+	Scopes       []string
+}
+
+// OAuthServerService lets third-party apps act for users that authorized
+// them, with the authorization code and refresh token grants.
+type OAuthServerService struct {
+	DB                 *database.Queries
+	JWTConfig          *auth.JWTConfig
+	CodeExpiry         time.Duration
+	RefreshTokenExpiry time.Duration
+}
+
+func NewOAuthServerService(db *database.Queries, jwtConfig *auth.JWTConfig, codeExpiry, refreshTokenExpiry time.Duration) *OAuthServerService {
+	return &OAuthServerService{
+		DB:                 db,
+		JWTConfig:          jwtConfig,
+		CodeExpiry:         codeExpiry,
+		RefreshTokenExpiry: refreshTokenExpiry,
+	}
+}
+
+// RegisterClient creates an app. Public apps, such as mobile or watch apps
+// that can't keep a secret, get no secret and have to use PKCE.
+func (s *OAuthServerService) RegisterClient(ctx context.Context, ownerID uuid.UUID, name string, redirectURIs []string, public bool) (database.OauthClient, string, error) {
+	for _, uri := range redirectURIs {
+		if !validRedirectURI(uri) {
+			return database.OauthClient{}, "", fmt.Errorf("%w: %s", ErrInvalidRedirectURI, uri)
+		}
+	}
+	id, err := generateOpaqueToken()
+	if err != nil {
+		return database.OauthClient{}, "", err
+	}
+	var secret, secretHash string
+	if !public {
+		secret, err = generateOpaqueToken()
+		if err != nil {
+			return database.OauthClient{}, "", err
+		}
+		secretHash = hashToken(secret)
+	}
+	client, err := s.DB.CreateOAuthClient(ctx, database.CreateOAuthClientParams{
+		ClientID:         oauthClientIDPrefix + id[:16],
+		ClientSecretHash: secretHash,
+		OwnerID:          ownerID,
+		Name:             name,
+		RedirectUris:     redirectURIs,
+	})
+	if err != nil {
+		return database.OauthClient{}, "", err
+	}
+	return client, secret, nil
+}
+
+// validRedirectURI accepts absolute URLs without fragment. Plain http is only
+// allowed for loopback addresses, custom schemes are fine for native apps.
+func validRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" || u.Fragment != "" {
+		return false
+	}
+	if u.Scheme == "http" {
+		host := u.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	}
+	return u.Scheme != "javascript" && u.Scheme != "data"
+}
+
+func (s *OAuthServerService) DeleteClient(ctx context.Context, ownerID uuid.UUID, clientID string) error {
+	deleted, err := s.DB.DeleteOwnedOAuthClient(ctx, database.DeleteOwnedOAuthClientParams{
+		ClientID: clientID,
+		OwnerID:  ownerID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// ValidateAuthorization checks a request before the consent screen is shown
+// and fills in the redirect URI if the app has only one. ErrOAuthClientNotFound
+// and ErrInvalidRedirectURI must be shown to the user, every other error goes
+// back to the app through the redirect URI.
+func (s *OAuthServerService) ValidateAuthorization(ctx context.Context, req *AuthorizationRequest) (database.OauthClient, []string, error) {
+	client, err := s.DB.GetOAuthClient(ctx, req.ClientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.OauthClient{}, nil, ErrOAuthClientNotFound
+	}
+	if err != nil {
+		return database.OauthClient{}, nil, err
+	}
+	if req.RedirectURI == "" && len(client.RedirectUris) == 1 {
+		req.RedirectURI = client.RedirectUris[0]
+	}
+	if !slices.Contains(client.RedirectUris, req.RedirectURI) {
+		return database.OauthClient{}, nil, ErrInvalidRedirectURI
+	}
+	if req.ResponseType != "code" {
+		return client, nil, oauthError("unsupported_response_type", "only the code response type is supported")
+	}
+	scopes, err := parseScopes(req.Scope)
+	if err != nil {
+		return client, nil, err
+	}
+	if req.CodeChallenge != "" && req.CodeChallengeMethod != "S256" {
+		return client, nil, oauthError("invalid_request", "only the S256 code challenge method is supported")
+	}
+	if req.CodeChallenge == "" && client.ClientSecretHash == "" {
+		return client, nil, oauthError("invalid_request", "public clients have to use PKCE")
+	}
+	return client, scopes, nil
+}
+
+func parseScopes(scope string) ([]string, error) {
+	var scopes []string
+	for _, sc := range strings.Fields(scope) {
+		if !auth.ValidScope(sc) {
+			return nil, oauthError("invalid_scope", "unknown scope "+sc)
+		}
+		if !slices.Contains(scopes, sc) {
+			scopes = append(scopes, sc)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, oauthError("invalid_scope", "scope is required")
+	}
+	return scopes, nil
+}
+
+// Authorize records the user's consent and returns the redirect URI with a
+// fresh authorization code.
+func (s *OAuthServerService) Authorize(ctx context.Context, userID uuid.UUID, req AuthorizationRequest) (string, error) {
+	// checked before validation fills in the default
+	explicitRedirect := req.RedirectURI != ""
+	client, scopes, err := s.ValidateAuthorization(ctx, &req)
+	if err != nil {
+		return "", err
+	}
+	grant, err := s.DB.UpsertOAuthGrant(ctx, database.UpsertOAuthGrantParams{
+		UserID:   userID,
+		ClientID: client.ClientID,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return "", err
+	}
+	code, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.DB.CreateOAuthAuthorizationCode(ctx, database.CreateOAuthAuthorizationCodeParams{
+		CodeHash:            hashToken(code),
+		GrantID:             grant.ID,
+		RedirectUri:         req.RedirectURI,
+		RedirectUriExplicit: explicitRedirect,
+		Scopes:              scopes,
+		CodeChallenge:       req.CodeChallenge,
+		ExpiresAt:           time.Now().Add(s.CodeExpiry),
+	})
+	if err != nil {
+		return "", err
+	}
+	return redirectWith(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}), nil
+}
+
+// ErrorRedirect returns the redirect URI that reports err, or a denied
+// consent when err is nil, to the app.
+func ErrorRedirect(req AuthorizationRequest, err error) string {
+	params := url.Values{"error": {"access_denied"}, "state": {req.State}}
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) {
+		params.Set("error", oauthErr.Code)
+		params.Set("error_description", oauthErr.Description)
+	} else if err != nil {
+		params.Set("error", "server_error")
+	}
+	return redirectWith(req.RedirectURI, params)
+}
+
+func redirectWith(redirectURI string, params url.Values) string {
+	u, _ := url.Parse(redirectURI)
+	q := u.Query()
+	for k, v := range params {
+		if v[0] != "" {
+			q[k] = v
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// ExchangeCode implements the authorization_code grant.
+func (s *OAuthServerService) ExchangeCode(ctx context.Context, clientID, clientSecret, code, redirectURI, codeVerifier string) (ClientTokens, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	stored, err := s.DB.ConsumeOAuthAuthorizationCode(ctx, hashToken(code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ClientTokens{}, oauthError("invalid_grant", "authorization code invalid or expired")
+	}
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	grant, err := s.DB.GetOAuthGrantByID(ctx, stored.GrantID)
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	if grant.ClientID != client.ClientID || !redirectURIMatches(stored, redirectURI) {
+		return ClientTokens{}, oauthError("invalid_grant", "authorization code was issued for another client or redirect uri")
+	}
+	if stored.CodeChallenge != "" && subtle.ConstantTimeCompare([]byte(oauth.CodeChallenge(codeVerifier)), []byte(stored.CodeChallenge)) != 1 {
+		return ClientTokens{}, oauthError("invalid_grant", "code verifier does not match")
+	}
+	return s.issueTokens(ctx, grant, stored.Scopes)
+}
+
+// redirectURIMatches checks the redirect_uri of a token request against the
+// code. One sent with the authorization request must come back unchanged,
+// one left to the default may be left out.
+func redirectURIMatches(code database.OauthAuthorizationCode, redirectURI string) bool {
+	if redirectURI == "" {
+		return !code.RedirectUriExplicit
+	}
+	return redirectURI == code.RedirectUri
+}
+
+// Refresh implements the refresh_token grant. Refresh tokens are rotated, a
+// reused one revokes the whole authorization.
+func (s *OAuthServerService) Refresh(ctx context.Context, clientID, clientSecret, refreshToken string) (ClientTokens, error) {
+	client, err := s.authenticateClient(ctx, clientID, clientSecret)
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	stored, err := s.DB.RotateOAuthRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		s.handleRefreshTokenReuse(ctx, refreshToken)
+		return ClientTokens{}, oauthError("invalid_grant", "refresh token invalid or expired")
+	}
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	grant, err := s.DB.GetOAuthGrantByID(ctx, stored.GrantID)
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	if grant.ClientID != client.ClientID {
+		return ClientTokens{}, oauthError("invalid_grant", "refresh token was issued to another client")
+	}
+	// the user may have granted fewer scopes since
+	var scopes []string
+	for _, sc := range stored.Scopes {
+		if slices.Contains(grant.Scopes, sc) {
+			scopes = append(scopes, sc)
+		}
+	}
+	if len(scopes) == 0 {
+		return ClientTokens{}, oauthError("invalid_grant", "no scopes left")
+	}
+	return s.issueTokens(ctx, grant, scopes)
+}
+
+func (s *OAuthServerService) handleRefreshTokenReuse(ctx context.Context, refreshToken string) {
+	stored, err := s.DB.GetOAuthRefreshToken(ctx, hashToken(refreshToken))
+	if err != nil || !stored.RotatedAt.Valid {
+		return
+	}
+	grant, err := s.DB.GetOAuthGrantByID(ctx, stored.GrantID)
+	if err != nil {
+		return
+	}
+	if err := s.DB.DeleteOAuthGrant(ctx, grant.ID); err != nil {
+		log.Printf("failed to revoke app authorization after refresh token reuse: %v", err)
+		return
+	}
+	err = s.DB.CreateAuthEvent(ctx, database.CreateAuthEventParams{
+		UserID:    uuid.NullUUID{UUID: grant.UserID, Valid: true},
+		EventType: EventTokenTheftDetected,
+		Details:   "refresh token of app " + grant.ClientID + " reused, authorization revoked",
+	})
+	if err != nil {
+		log.Printf("failed to record auth event: %v", err)
+	}
+}
+
+// authenticateClient checks the secret of confidential clients. Public
+// clients only send their id.
+func (s *OAuthServerService) authenticateClient(ctx context.Context, clientID, clientSecret string) (database.OauthClient, error) {
+	client, err := s.DB.GetOAuthClient(ctx, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.OauthClient{}, oauthError("invalid_client", "unknown client")
+	}
+	if err != nil {
+		return database.OauthClient{}, err
+	}
+	if client.ClientSecretHash != "" && subtle.ConstantTimeCompare([]byte(hashToken(clientSecret)), []byte(client.ClientSecretHash)) != 1 {
+		return database.OauthClient{}, oauthError("invalid_client", "client authentication failed")
+	}
+	return client, nil
+}
+
+func (s *OAuthServerService) issueTokens(ctx context.Context, grant database.OauthGrant, scopes []string) (ClientTokens, error) {
+	user, err := s.DB.GetUser(ctx, grant.UserID)
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	if user.DeletionScheduledAt.Valid {
+		return ClientTokens{}, oauthError("invalid_grant", "account is scheduled for deletion")
+	}
+	accessToken, err := s.JWTConfig.GenerateClientAccessToken(user.ID.String(), user.Role, user.Email, grant.ClientID, scopes)
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	refreshToken, err := generateOpaqueToken()
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	err = s.DB.CreateOAuthRefreshToken(ctx, database.CreateOAuthRefreshTokenParams{
+		TokenHash: hashToken(refreshToken),
+		GrantID:   grant.ID,
+		Scopes:    scopes,
+		ExpiresAt: time.Now().Add(s.RefreshTokenExpiry),
+	})
+	if err != nil {
+		return ClientTokens{}, err
+	}
+	return ClientTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.JWTConfig.AccessTokenExpiry.Seconds()),
+		Scopes:       scopes,
+	}, nil
+}
+
+// RevokeGrant removes an app from the user's authorized apps together with
+// its refresh tokens. Access tokens stay valid until they expire.
+func (s *OAuthServerService) RevokeGrant(ctx context.Context, userID uuid.UUID, clientID string) error {
+	deleted, err := s.DB.DeleteUserOAuthGrant(ctx, database.DeleteUserOAuthGrantParams{
+		UserID:   userID,
+		ClientID: clientID,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrOAuthGrantNotFound
+	}
+	return nil
+}
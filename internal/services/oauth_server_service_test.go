@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+func TestValidRedirectURI(t *testing.T) {
+	tests := map[string]bool{
+		"https://app.example.com/callback": true,
+		"http://localhost:3000/callback":   true,
+		"http://127.0.0.1/callback":        true,
+		"fitwatch://oauth":                 true,
+		"http://app.example.com/callback":  false,
+		"https://app.example.com/cb#frag":  false,
+		"/relative/callback":               false,
+		"javascript:alert(1)":              false,
+	}
+	for uri, want := range tests {
+		if got := validRedirectURI(uri); got != want {
+			t.Errorf("validRedirectURI(%q) = %v, want %v", uri, got, want)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := parseScopes("workouts:read workouts:write workouts:read")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 2 {
+		t.Errorf("expected duplicates to be dropped, got %v", scopes)
+	}
+	var oauthErr *OAuthError
+	if _, err := parseScopes("workouts:read admin"); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_scope" {
+		t.Errorf("unknown scope: got %v, want invalid_scope", err)
+	}
+	if _, err := parseScopes(""); !errors.As(err, &oauthErr) || oauthErr.Code != "invalid_scope" {
+		t.Errorf("empty scope: got %v, want invalid_scope", err)
+	}
+}
+
+func TestErrorRedirect(t *testing.T) {
+	req := AuthorizationRequest{RedirectURI: "https://app.example.com/cb?keep=1", State: "xyz"}
+
+	u, _ := url.Parse(ErrorRedirect(req, nil))
+	if q := u.Query(); q.Get("error") != "access_denied" || q.Get("state") != "xyz" || q.Get("keep") != "1" {
+		t.Errorf("denied consent: unexpected redirect %s", u)
+	}
+
+	u, _ = url.Parse(ErrorRedirect(req, oauthError("invalid_scope", "scope is required")))
+	if q := u.Query(); q.Get("error") != "invalid_scope" || q.Get("error_description") != "scope is required" {
+		t.Errorf("oauth error: unexpected redirect %s", u)
+	}
+}
+
+func TestRedirectURIMatches(t *testing.T) {
+	explicit := database.OauthAuthorizationCode{RedirectUri: "https://app.example.com/callback", RedirectUriExplicit: true}
+	defaulted := database.OauthAuthorizationCode{RedirectUri: "https://app.example.com/callback"}
+	tests := []struct {
+		code        database.OauthAuthorizationCode
+		redirectURI string
+		want        bool
+	}{
+		{explicit, "https://app.example.com/callback", true},
+		{explicit, "", false},
+		{explicit, "https://app.example.com/other", false},
+		{defaulted, "", true},
+		{defaulted, "https://app.example.com/callback", true},
+		{defaulted, "https://app.example.com/other", false},
+	}
+	for _, tt := range tests {
+		if got := redirectURIMatches(tt.code, tt.redirectURI); got != tt.want {
+			t.Errorf("redirectURIMatches(explicit=%v, %q) = %v, want %v", tt.code.RedirectUriExplicit, tt.redirectURI, got, tt.want)
+		}
+	}
+}
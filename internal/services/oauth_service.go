@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/oauth"
+)
+
+var (
+	ErrUnknownProvider       = errors.New("unknown identity provider")
+	ErrInvalidOAuthState     = errors.New("oauth state invalid or expired")
+	ErrIdentityInUse         = errors.New("identity is linked to another account")
+	ErrProviderAlreadyLinked = errors.New("provider already linked")
+	ErrOAuthEmailTaken       = errors.New("an account with this email already exists")
+	ErrOAuthEmailUnverified  = errors.New("provider did not return a verified email")
+	ErrIdentityNotFound      = errors.New("identity not found")
+)
+
+type OAuthService struct {
+	DB          *database.Queries
+	Providers   map[string]oauth.Provider
+	StateExpiry time.Duration
+}
+
+func NewOAuthService(db *database.Queries, stateExpiry time.Duration, providers ...oauth.Provider) *OAuthService {
+	s := &OAuthService{
+		DB:          db,
+		Providers:   make(map[string]oauth.Provider, len(providers)),
+		StateExpiry: stateExpiry,
+	}
+	for _, p := range providers {
+		s.Providers[p.Name()] = p
+	}
+	return s
+}
+
+func (s *OAuthService) ProviderNames() []string {
+	names := make([]string, 0, len(s.Providers))
+	for name := range s.Providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OAuthResult tells the caller what Complete did for whom.
+type OAuthResult struct {
+	UserID        uuid.UUID
+	Linked        bool
+	Created       bool
+	CookieSession bool
+}
+
+// Begin stores a new state with its PKCE verifier and nonce and returns the
+// URL the browser has to be sent to together with the state, which the caller
+// has to bind to the browser. With linkUserID set, the callback links the
+// identity to that user instead of signing in.
+func (s *OAuthService) Begin(ctx context.Context, providerName string, linkUserID uuid.NullUUID, cookieSession bool) (string, string, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+	// abandoned logins would pile up otherwise
+	if err := s.DB.CleanExpiredOAuthStates(ctx); err != nil {
+		return "", "", err
+	}
+	state, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := generateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth.NewCodeVerifier()
+	err = s.DB.CreateOAuthState(ctx, database.CreateOAuthStateParams{
+		StateHash:     hashToken(state),
+		Provider:      providerName,
+		CodeVerifier:  verifier,
+		Nonce:         nonce,
+		LinkUserID:    linkUserID,
+		CookieSession: cookieSession,
+		ExpiresAt:     time.Now().Add(s.StateExpiry),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	authURL, err := provider.AuthCodeURL(ctx, state, oauth.CodeChallenge(verifier), nonce)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// Complete handles the provider callback. Known identities sign in, new ones
+// get a fresh account. Existing accounts are never taken over by email, the
+// user has to sign in and link the provider first.
+func (s *OAuthService) Complete(ctx context.Context, providerName, state, code string) (OAuthResult, error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return OAuthResult{}, ErrUnknownProvider
+	}
+	st, err := s.DB.ConsumeOAuthState(ctx, database.ConsumeOAuthStateParams{
+		StateHash: hashToken(state),
+		Provider:  providerName,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return OAuthResult{}, ErrInvalidOAuthState
+	}
+	if err != nil {
+		return OAuthResult{}, err
+	}
+	identity, err := provider.Exchange(ctx, code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		return OAuthResult{}, err
+	}
+	result := OAuthResult{CookieSession: st.CookieSession}
+
+	existing, err := s.DB.GetUserIdentityBySubject(ctx, database.GetUserIdentityBySubjectParams{
+		Provider: providerName,
+		Subject:  identity.Subject,
+	})
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return OAuthResult{}, err
+	}
+
+	if st.LinkUserID.Valid {
+		result.UserID = st.LinkUserID.UUID
+		result.Linked = true
+		if found {
+			if existing.UserID != st.LinkUserID.UUID {
+				return OAuthResult{}, ErrIdentityInUse
+			}
+			return result, nil
+		}
+		if err := s.createIdentity(ctx, st.LinkUserID.UUID, providerName, identity); err != nil {
+			return OAuthResult{}, err
+		}
+		return result, nil
+	}
+
+	if found {
+		result.UserID = existing.UserID
+		return result, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return OAuthResult{}, ErrOAuthEmailUnverified
+	}
+	_, err = s.DB.GetUserLogin(ctx, identity.Email)
+	if err == nil {
+		return OAuthResult{}, ErrOAuthEmailTaken
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return OAuthResult{}, err
+	}
+	user, err := s.createUser(ctx, identity)
+	if err != nil {
+		return OAuthResult{}, err
+	}
+	if err := s.createIdentity(ctx, user.ID, providerName, identity); err != nil {
+		return OAuthResult{}, err
+	}
+	result.UserID = user.ID
+	result.Created = true
+	return result, nil
+}
+
+func (s *OAuthService) createUser(ctx context.Context, identity *oauth.Identity) (database.User, error) {
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	// nobody knows this password, it can be set later with a password reset
+	hashedPassword, err := argon2id.CreateHash(rand.Text(), argon2id.DefaultParams)
+	if err != nil {
+		return database.User{}, err
+	}
+	user, err := s.DB.CreateUser(ctx, database.CreateUserParams{
+		Name:           name,
+		Email:          identity.Email,
+		HashedPassword: hashedPassword,
+	})
+	if isUniqueViolation(err) {
+		return database.User{}, ErrOAuthEmailTaken
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+	// the provider already verified the address
+	if _, err := s.DB.MarkEmailVerified(ctx, database.MarkEmailVerifiedParams{ID: user.ID, Email: user.Email}); err != nil {
+		return database.User{}, err
+	}
+	return user, nil
+}
+
+func (s *OAuthService) createIdentity(ctx context.Context, userID uuid.UUID, providerName string, identity *oauth.Identity) error {
+	_, err := s.DB.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		UserID:   userID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		if pqErr.Constraint == "user_identities_provider_subject_key" {
+			return ErrIdentityInUse
+		}
+		return ErrProviderAlreadyLinked
+	}
+	return err
+}
+
+func (s *OAuthService) Unlink(ctx context.Context, userID uuid.UUID, providerName string) error {
+	deleted, err := s.DB.DeleteUserIdentity(ctx, database.DeleteUserIdentityParams{
+		UserID:   userID,
+		Provider: providerName,
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrIdentityNotFound
+	}
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+	"github.com/sssseraphim/fitterBy/internal/mail"
+)
+
+var ErrInvalidResetToken = errors.New("reset token invalid, used or expired")
+
+type PasswordResetService struct {
+	DB      *database.Queries
+	Mailer  mail.Mailer
+	BaseURL string
+	Expiry  time.Duration
+}
+
+func NewPasswordResetService(db *database.Queries, mailer mail.Mailer, baseURL string, expiry time.Duration) *PasswordResetService {
+	return &PasswordResetService{
+		DB:      db,
+		Mailer:  mailer,
+		BaseURL: baseURL,
+		Expiry:  expiry,
+	}
+}
+
+// RequestReset emails a single-use reset token to the user with the given email.
+// Unknown emails are silently ignored so callers can't probe for accounts.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	user, err := s.DB.GetUserLogin(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	err = s.DB.CreatePasswordResetToken(ctx, database.CreatePasswordResetTokenParams{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.Expiry),
+	})
+	if err != nil {
+		return err
+	}
+	return s.Mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your fitterBy password",
+		Body: fmt.Sprintf("Hi %s,\n\nsomeone asked to reset the password of your fitterBy account.\n"+
+			"Open the link below to choose a new one, it is valid for %v:\n\n%s/reset-password?token=%s\n\n"+
+			"If it wasn't you, just ignore this email.\n", user.Name, s.Expiry, s.BaseURL, token),
+	})
+}
+
+// ResetPassword consumes the reset token and stores the new password hash.
+// It returns the id of the user whose password was changed.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, hashedPassword string) (uuid.UUID, error) {
+	userID, err := s.DB.ConsumePasswordResetToken(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrInvalidResetToken
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	err = s.DB.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{
+		ID:             userID,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if err := s.DB.DeleteUserPasswordResetTokens(ctx, userID); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// generateOpaqueToken returns a random url safe token.
+func generateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
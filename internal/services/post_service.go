@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+// MaxCommentDepth is how deep replies can nest, top level comments are at
+// depth 0.
+const MaxCommentDepth = 3
+
+var (
+	ErrPostNotFound    = errors.New("post not found")
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrNotAuthor       = errors.New("only the author or a moderator can change this")
+	ErrEmptyComment    = errors.New("comment can't be empty")
+	ErrCommentTooDeep  = errors.New("replies can't be nested any deeper")
+
+	ErrRepostPrivate     = errors.New("private posts can't be reshared")
+	ErrAlreadyReposted   = errors.New("post already reposted")
+	ErrRepostAttachments = errors.New("plain reposts can't have images, workouts or programs")
+	ErrEmptyQuote        = errors.New("quote posts can't be emptied, repost the post instead")
+)
+
+// Editor is the user changing a post or comment. Moderators may change
+// anyone's, everybody else only their own.
+type Editor struct {
+	ID        uuid.UUID
+	Moderator bool
+}
+
+// CanChange reports whether the editor may change or see the history of
+// something written by authorID.
+func (e Editor) CanChange(authorID uuid.UUID) bool {
+	return e.Moderator || e.ID == authorID
+}
+
+// PostEdit holds the fields of a partial post edit, nil fields are left as
+// they are.
+type PostEdit struct {
+	Content    *string
+	MediaUrls  *[]string
+	Visibility *string
+}
+
+// PostService creates posts, adds comments and edits and deletes posts and
+// comments. Every edit stores the replaced content as a revision, deletes only hide the row
+// so replies and counts around it stay consistent.
+type PostService struct {
+	DB   *database.Queries
+	Conn *sql.DB
+}
+
+func NewPostService(db *database.Queries, conn *sql.DB) *PostService {
+	return &PostService{DB: db, Conn: conn}
+}
+
+// CreatePost creates a post and tags it with the tags used in its content.
+func (s *PostService) CreatePost(ctx context.Context, params database.CreatePostParams) (database.Post, error) {
+	if !ValidVisibility(params.Visibility) {
+		return database.Post{}, ErrInvalidVisibility
+	}
+	var post database.Post
+	err := s.inTx(ctx, func(q *database.Queries) error {
+		var err error
+		post, err = q.CreatePost(ctx, params)
+		if err != nil {
+			return err
+		}
+		return tagPost(ctx, q, post.ID, post.Content)
+	})
+	return post, err
+}
+
+// IsPlainRepost reports whether a post reposts another one without content
+// of its own. Reposts with content are quote posts.
+func IsPlainRepost(content string, repostOf uuid.NullUUID) bool {
+	return repostOf.Valid && content == ""
+}
+
+// Repost reposts original, quoting it when params has content. The caller
+// checks that the user may see original, and passes the post a plain repost
+// reposts instead of the plain repost itself.
+func (s *PostService) Repost(ctx context.Context, params database.CreatePostParams, original database.GetPostRow) (database.Post, error) {
+	params, err := repostParams(params, original)
+	if err != nil {
+		return database.Post{}, err
+	}
+	post, err := s.CreatePost(ctx, params)
+	if isUniqueViolation(err) {
+		return database.Post{}, ErrAlreadyReposted
+	}
+	return post, err
+}
+
+// repostParams applies the repost rules to params. Private posts stay with
+// their author, and reposts never reach further than the original: their
+// visibility is narrowed down to the original's.
+func repostParams(params database.CreatePostParams, original database.GetPostRow) (database.CreatePostParams, error) {
+	if original.Visibility == VisibilityPrivate {
+		return params, ErrRepostPrivate
+	}
+	if strings.TrimSpace(params.Content) == "" {
+		params.Content = ""
+		if len(params.MediaUrls) > 0 || params.WorkoutID.Valid || params.ProgramID.Valid {
+			return params, ErrRepostAttachments
+		}
+	}
+	params.Visibility = repostVisibility(params.Visibility, original.Visibility)
+	params.RepostOf = uuid.NullUUID{UUID: original.ID, Valid: true}
+	return params, nil
+}
+
+// repostVisibility narrows the visibility of a repost down to the one of the
+// original.
+func repostVisibility(visibility, original string) string {
+	if original == VisibilityFollowers && visibility == VisibilityPublic {
+		return VisibilityFollowers
+	}
+	return visibility
+}
+
+// Comment adds a comment to the post, or a reply to parentID when it is
+// valid. The caller checks that the user may see the post.
+func (s *PostService) Comment(ctx context.Context, userID, postID uuid.UUID, parentID uuid.NullUUID, content string) (database.PostsComment, error) {
+	if strings.TrimSpace(content) == "" {
+		return database.PostsComment{}, ErrEmptyComment
+	}
+	params := database.CommentOnPostParams{
+		UserID:  uuid.NullUUID{UUID: userID, Valid: true},
+		PostID:  postID,
+		Content: content,
+	}
+	if parentID.Valid {
+		parent, err := s.DB.GetComment(ctx, parentID.UUID)
+		if errors.Is(err, sql.ErrNoRows) || err == nil && (parent.PostID != postID || parent.DeletedAt.Valid) {
+			return database.PostsComment{}, ErrCommentNotFound
+		}
+		if err != nil {
+			return database.PostsComment{}, err
+		}
+		if parent.Depth >= MaxCommentDepth {
+			return database.PostsComment{}, ErrCommentTooDeep
+		}
+		blocked, err := s.DB.IsBlockedEitherWay(ctx, database.IsBlockedEitherWayParams{
+			BlockerID: userID,
+			BlockedID: parent.UserID.UUID,
+		})
+		if err != nil {
+			return database.PostsComment{}, err
+		}
+		if blocked {
+			return database.PostsComment{}, ErrCommentNotFound
+		}
+		params.ParentCommentID = parentID
+		params.RootCommentID = parent.RootCommentID
+		if !parent.RootCommentID.Valid {
+			params.RootCommentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
+		params.Depth = parent.Depth + 1
+	}
+	return s.DB.CommentOnPost(ctx, params)
+}
+
+func (s *PostService) EditPost(ctx context.Context, editor Editor, postID uuid.UUID, edit PostEdit) (database.Post, error) {
+	if edit.Visibility != nil && !ValidVisibility(*edit.Visibility) {
+		return database.Post{}, ErrInvalidVisibility
+	}
+	var updated database.Post
+	err := s.inTx(ctx, func(q *database.Queries) error {
+		post, err := q.LockPostForEdit(ctx, postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !editor.CanChange(post.UserID) {
+			return ErrNotAuthor
+		}
+		updated = post
+		if !applyPostEdit(&updated, edit) {
+			return nil
+		}
+		if updated.RepostOf.Valid && strings.TrimSpace(updated.Content) == "" {
+			if post.Content != "" {
+				return ErrEmptyQuote
+			}
+			if len(updated.MediaUrls) > 0 {
+				return ErrRepostAttachments
+			}
+		}
+		if updated.RepostOf.Valid && updated.Visibility != post.Visibility {
+			original, err := q.GetPost(ctx, updated.RepostOf.UUID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if err == nil {
+				updated.Visibility = repostVisibility(updated.Visibility, original.Visibility)
+			}
+			if updated.Visibility == post.Visibility && updated.Content == post.Content && slices.Equal(updated.MediaUrls, post.MediaUrls) {
+				return nil
+			}
+		}
+		err = q.CreatePostRevision(ctx, database.CreatePostRevisionParams{
+			PostID:     post.ID,
+			EditorID:   uuid.NullUUID{UUID: editor.ID, Valid: true},
+			Content:    post.Content,
+			MediaUrls:  post.MediaUrls,
+			Visibility: post.Visibility,
+		})
+		if err != nil {
+			return err
+		}
+		updated, err = q.UpdatePost(ctx, database.UpdatePostParams{
+			ID:         post.ID,
+			Content:    updated.Content,
+			MediaUrls:  updated.MediaUrls,
+			Visibility: updated.Visibility,
+		})
+		if err != nil {
+			return err
+		}
+		if updated.Content == post.Content {
+			return nil
+		}
+		return tagPost(ctx, q, post.ID, updated.Content)
+	})
+	return updated, err
+}
+
+// applyPostEdit copies the edit onto post and reports whether anything changed.
+func applyPostEdit(post *database.Post, edit PostEdit) bool {
+	changed := false
+	if edit.Content != nil && *edit.Content != post.Content {
+		post.Content = *edit.Content
+		changed = true
+	}
+	if edit.MediaUrls != nil && !slices.Equal(*edit.MediaUrls, post.MediaUrls) {
+		post.MediaUrls = *edit.MediaUrls
+		changed = true
+	}
+	if edit.Visibility != nil && *edit.Visibility != post.Visibility {
+		post.Visibility = *edit.Visibility
+		changed = true
+	}
+	return changed
+}
+
+func (s *PostService) DeletePost(ctx context.Context, editor Editor, postID uuid.UUID) error {
+	return s.inTx(ctx, func(q *database.Queries) error {
+		post, err := q.LockPostForEdit(ctx, postID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !editor.CanChange(post.UserID) {
+			return ErrNotAuthor
+		}
+		return q.SoftDeletePost(ctx, post.ID)
+	})
+}
+
+// EditComment replaces the content of a comment. Comments of deleted posts
+// can't be changed anymore.
+func (s *PostService) EditComment(ctx context.Context, editor Editor, commentID uuid.UUID, content string) (database.PostsComment, error) {
+	if strings.TrimSpace(content) == "" {
+		return database.PostsComment{}, ErrEmptyComment
+	}
+	var updated database.PostsComment
+	err := s.inTx(ctx, func(q *database.Queries) error {
+		comment, err := q.LockCommentForEdit(ctx, commentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !editor.CanChange(comment.UserID.UUID) {
+			return ErrNotAuthor
+		}
+		updated = comment
+		if content == comment.Content {
+			return nil
+		}
+		err = q.CreateCommentRevision(ctx, database.CreateCommentRevisionParams{
+			CommentID: comment.ID,
+			EditorID:  uuid.NullUUID{UUID: editor.ID, Valid: true},
+			Content:   comment.Content,
+		})
+		if err != nil {
+			return err
+		}
+		updated, err = q.UpdateComment(ctx, database.UpdateCommentParams{
+			ID:      comment.ID,
+			Content: content,
+		})
+		return err
+	})
+	return updated, err
+}
+
+func (s *PostService) DeleteComment(ctx context.Context, editor Editor, commentID uuid.UUID) error {
+	return s.inTx(ctx, func(q *database.Queries) error {
+		comment, err := q.LockCommentForEdit(ctx, commentID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !editor.CanChange(comment.UserID.UUID) {
+			return ErrNotAuthor
+		}
+		return q.SoftDeleteComment(ctx, comment.ID)
+	})
+}
+
+func (s *PostService) inTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := s.Conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(s.DB.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+func TestApplyPostEdit(t *testing.T) {
+	content := "new content"
+	same := "old content"
+	media := []string{"https://example.com/a.jpg"}
+	followers := VisibilityFollowers
+
+	tests := []struct {
+		name    string
+		edit    PostEdit
+		changed bool
+	}{
+		{"nothing", PostEdit{}, false},
+		{"same content", PostEdit{Content: &same}, false},
+		{"content", PostEdit{Content: &content}, true},
+		{"media", PostEdit{MediaUrls: &media}, true},
+		{"visibility", PostEdit{Visibility: &followers}, true},
+	}
+	for _, tt := range tests {
+		post := database.Post{Content: "old content", Visibility: VisibilityPublic}
+		if got := applyPostEdit(&post, tt.edit); got != tt.changed {
+			t.Errorf("%s: changed = %v, want %v", tt.name, got, tt.changed)
+		}
+	}
+}
+
+func TestEditorCanChange(t *testing.T) {
+	author := uuid.New()
+	if !(Editor{ID: author}).CanChange(author) {
+		t.Error("authors must be able to change their content")
+	}
+	if (Editor{ID: uuid.New()}).CanChange(author) {
+		t.Error("other users must not be able to change the content")
+	}
+	if !(Editor{ID: uuid.New(), Moderator: true}).CanChange(author) {
+		t.Error("moderators must be able to change any content")
+	}
+}
+
+func TestRepostParams(t *testing.T) {
+	original := database.GetPostRow{ID: uuid.New(), Visibility: VisibilityPublic}
+	followersOnly := original
+	followersOnly.Visibility = VisibilityFollowers
+	private := original
+	private.Visibility = VisibilityPrivate
+	media := []string{"https://example.com/a.jpg"}
+
+	tests := []struct {
+		name       string
+		params     database.CreatePostParams
+		original   database.GetPostRow
+		err        error
+		content    string
+		visibility string
+	}{
+		{"plain", database.CreatePostParams{Visibility: VisibilityPublic}, original, nil, "", VisibilityPublic},
+		{"quote", database.CreatePostParams{Content: "look", MediaUrls: media, Visibility: VisibilityPublic}, original, nil, "look", VisibilityPublic},
+		{"blank content is plain", database.CreatePostParams{Content: "  \n", Visibility: VisibilityPublic}, original, nil, "", VisibilityPublic},
+		{"plain with media", database.CreatePostParams{MediaUrls: media, Visibility: VisibilityPublic}, original, ErrRepostAttachments, "", ""},
+		{"plain with workout", database.CreatePostParams{WorkoutID: uuid.NullUUID{UUID: uuid.New(), Valid: true}, Visibility: VisibilityPublic}, original, ErrRepostAttachments, "", ""},
+		{"followers only is narrowed", database.CreatePostParams{Visibility: VisibilityPublic}, followersOnly, nil, "", VisibilityFollowers},
+		{"private repost of followers only", database.CreatePostParams{Visibility: VisibilityPrivate}, followersOnly, nil, "", VisibilityPrivate},
+		{"private", database.CreatePostParams{Content: "look", Visibility: VisibilityPublic}, private, ErrRepostPrivate, "", ""},
+	}
+	for _, tt := range tests {
+		got, err := repostParams(tt.params, tt.original)
+		if err != tt.err {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Content != tt.content || got.Visibility != tt.visibility {
+			t.Errorf("%s: got %q %s, want %q %s", tt.name, got.Content, got.Visibility, tt.content, tt.visibility)
+		}
+		if got.RepostOf != (uuid.NullUUID{UUID: tt.original.ID, Valid: true}) {
+			t.Errorf("%s: repost_of = %v, want %v", tt.name, got.RepostOf, tt.original.ID)
+		}
+	}
+}
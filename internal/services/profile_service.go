@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	WeightUnitKg = "kg"
+	WeightUnitLb = "lb"
+
+	maxNameLength = 50
+	maxBioLength  = 500
+)
+
+var (
+	ErrInvalidName       = errors.New("name must be 1 to 50 characters")
+	ErrInvalidBio        = errors.New("bio must be at most 500 characters")
+	ErrInvalidWeightUnit = errors.New("weight unit must be kg or lb")
+	ErrInvalidTimezone   = errors.New("unknown timezone")
+)
+
+// ProfileUpdate holds the fields of a partial profile update, nil fields are
+// left as they are.
+type ProfileUpdate struct {
+	Name *string
+	Bio  *string
+	// AvatarID is an image the user uploaded, uuid.Nil removes the avatar.
+	AvatarID          *uuid.UUID
+	WeightUnit        *string
+	Timezone          *string
+	ProfileVisibility *string
+}
+
+type ProfileService struct {
+	DB    *database.Queries
+	Media *MediaService
+}
+
+func NewProfileService(db *database.Queries, media *MediaService) *ProfileService {
+	return &ProfileService{DB: db, Media: media}
+}
+
+// Settings returns the settings of the user, with defaults for users who
+// never changed them.
+func (s *ProfileService) Settings(ctx context.Context, userID uuid.UUID) (database.UserSetting, error) {
+	settings, err := s.DB.GetUserSettings(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return defaultUserSettings(userID), nil
+	}
+	return settings, err
+}
+
+func defaultUserSettings(userID uuid.UUID) database.UserSetting {
+	return database.UserSetting{
+		UserID:            userID,
+		WeightUnit:        WeightUnitKg,
+		Timezone:          "UTC",
+		ProfileVisibility: VisibilityPublic,
+	}
+}
+
+// Update applies the non-nil fields of the update after validating them.
+func (s *ProfileService) Update(ctx context.Context, userID uuid.UUID, update ProfileUpdate) (database.GetUserRow, database.UserSetting, error) {
+	user, err := s.DB.GetUser(ctx, userID)
+	if err != nil {
+		return database.GetUserRow{}, database.UserSetting{}, err
+	}
+	settings, err := s.Settings(ctx, userID)
+	if err != nil {
+		return database.GetUserRow{}, database.UserSetting{}, err
+	}
+	if err := applyProfileUpdate(&user, &settings, update); err != nil {
+		return database.GetUserRow{}, database.UserSetting{}, err
+	}
+	if update.AvatarID != nil {
+		settings.AvatarUrl = ""
+		if *update.AvatarID != uuid.Nil {
+			urls, err := s.Media.URLs(ctx, userID, []uuid.UUID{*update.AvatarID})
+			if err != nil {
+				return database.GetUserRow{}, database.UserSetting{}, err
+			}
+			settings.AvatarUrl = urls[0]
+		}
+	}
+
+	if update.Name != nil || update.Bio != nil {
+		err := s.DB.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+			ID:   userID,
+			Name: user.Name,
+			Bio:  user.Bio,
+		})
+		if err != nil {
+			return database.GetUserRow{}, database.UserSetting{}, err
+		}
+	}
+	if update.AvatarID != nil || update.WeightUnit != nil || update.Timezone != nil || update.ProfileVisibility != nil {
+		settings, err = s.DB.UpsertUserSettings(ctx, database.UpsertUserSettingsParams{
+			UserID:            userID,
+			AvatarUrl:         settings.AvatarUrl,
+			WeightUnit:        settings.WeightUnit,
+			Timezone:          settings.Timezone,
+			ProfileVisibility: settings.ProfileVisibility,
+		})
+		if err != nil {
+			return database.GetUserRow{}, database.UserSetting{}, err
+		}
+	}
+	// nobody is left waiting once the account is no longer private
+	if update.ProfileVisibility != nil && settings.ProfileVisibility != VisibilityPrivate {
+		if err := s.DB.AcceptAllFollowRequests(ctx, userID); err != nil {
+			return database.GetUserRow{}, database.UserSetting{}, err
+		}
+	}
+	return user, settings, nil
+}
+
+// applyProfileUpdate validates the update and copies it onto user and settings.
+func applyProfileUpdate(user *database.GetUserRow, settings *database.UserSetting, update ProfileUpdate) error {
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		if name == "" || len([]rune(name)) > maxNameLength {
+			return ErrInvalidName
+		}
+		user.Name = name
+	}
+	if update.Bio != nil {
+		if len([]rune(*update.Bio)) > maxBioLength {
+			return ErrInvalidBio
+		}
+		user.Bio = *update.Bio
+	}
+	if update.WeightUnit != nil {
+		if *update.WeightUnit != WeightUnitKg && *update.WeightUnit != WeightUnitLb {
+			return ErrInvalidWeightUnit
+		}
+		settings.WeightUnit = *update.WeightUnit
+	}
+	if update.Timezone != nil {
+		// LoadLocation also accepts "" and "Local", which mean the server's zone
+		if *update.Timezone == "" || *update.Timezone == "Local" {
+			return ErrInvalidTimezone
+		}
+		if _, err := time.LoadLocation(*update.Timezone); err != nil {
+			return ErrInvalidTimezone
+		}
+		settings.Timezone = *update.Timezone
+	}
+	if update.ProfileVisibility != nil {
+		if !ValidVisibility(*update.ProfileVisibility) {
+			return ErrInvalidVisibility
+		}
+		settings.ProfileVisibility = *update.ProfileVisibility
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestApplyProfileUpdate(t *testing.T) {
+	user := database.GetUserRow{Name: "Old", Bio: "old bio"}
+	settings := defaultUserSettings(uuid.New())
+	err := applyProfileUpdate(&user, &settings, ProfileUpdate{
+		Name:              ptr("  New Name "),
+		WeightUnit:        ptr(WeightUnitLb),
+		Timezone:          ptr("Europe/Minsk"),
+		ProfileVisibility: ptr(VisibilityPrivate),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "New Name" || user.Bio != "old bio" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if settings.WeightUnit != WeightUnitLb ||
+		settings.Timezone != "Europe/Minsk" || settings.ProfileVisibility != VisibilityPrivate {
+		t.Errorf("unexpected settings %+v", settings)
+	}
+}
+
+func TestApplyProfileUpdateRejectsInvalidFields(t *testing.T) {
+	tests := []struct {
+		update ProfileUpdate
+		want   error
+	}{
+		{ProfileUpdate{Name: ptr("   ")}, ErrInvalidName},
+		{ProfileUpdate{Name: ptr(strings.Repeat("a", maxNameLength+1))}, ErrInvalidName},
+		{ProfileUpdate{Bio: ptr(strings.Repeat("a", maxBioLength+1))}, ErrInvalidBio},
+		{ProfileUpdate{WeightUnit: ptr("stone")}, ErrInvalidWeightUnit},
+		{ProfileUpdate{Timezone: ptr("Mars/Olympus")}, ErrInvalidTimezone},
+		{ProfileUpdate{Timezone: ptr("Local")}, ErrInvalidTimezone},
+		{ProfileUpdate{ProfileVisibility: ptr("friends")}, ErrInvalidVisibility},
+	}
+	for _, tt := range tests {
+		user := database.GetUserRow{Name: "Old"}
+		settings := defaultUserSettings(uuid.New())
+		if err := applyProfileUpdate(&user, &settings, tt.update); !errors.Is(err, tt.want) {
+			t.Errorf("got %v, want %v", err, tt.want)
+		}
+	}
+}
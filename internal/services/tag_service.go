@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	// maxTags caps how many tags one post or program gets.
+	maxTags      = 10
+	maxTagLength = 50
+
+	DefaultTrendingWindow = 24 * time.Hour
+	MaxTrendingWindow     = 30 * 24 * time.Hour
+	trendingTagsLimit     = 20
+)
+
+var (
+	ErrInvalidTag  = errors.New("tags are up to 50 letters, digits or underscores with at least one letter")
+	ErrTooManyTags = errors.New("too many tags")
+)
+
+// tagPattern matches #tag at the start of the text or after a character that
+// can't be part of a tag, so html entities and link anchors aren't taken for
+// tags.
+var tagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#/])#([\p{L}\p{N}_]+)`)
+
+// TagService keeps the tags of posts and programs. Post tags are parsed from
+// the content, programs are tagged by their author.
+type TagService struct {
+	DB *database.Queries
+}
+
+func NewTagService(db *database.Queries) *TagService {
+	return &TagService{DB: db}
+}
+
+// NormalizeTag lower cases a tag, with or without its leading #, and checks
+// that it is a valid tag name.
+func NormalizeTag(tag string) (string, error) {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if name == "" || utf8.RuneCountInString(name) > maxTagLength {
+		return "", ErrInvalidTag
+	}
+	hasLetter := false
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return "", ErrInvalidTag
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	if !hasLetter {
+		return "", ErrInvalidTag
+	}
+	return name, nil
+}
+
+// NormalizeTags normalizes the tags given for a program and drops duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		name, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) > maxTags {
+		return nil, ErrTooManyTags
+	}
+	return names, nil
+}
+
+// parseTags returns the tags used in text, each once and at most maxTags of
+// them. Words that aren't valid tags, like #1, are skipped.
+func parseTags(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range tagPattern.FindAllStringSubmatch(text, -1) {
+		name, err := NormalizeTag(match[1])
+		if err != nil || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxTags {
+			break
+		}
+	}
+	return names
+}
+
+// tagPost replaces the tags of a post with the ones used in its content.
+func tagPost(ctx context.Context, q *database.Queries, postID uuid.UUID, content string) error {
+	if err := q.DeletePostTags(ctx, postID); err != nil {
+		return err
+	}
+	names := parseTags(content)
+	if len(names) == 0 {
+		return nil
+	}
+	if err := q.CreateTags(ctx, names); err != nil {
+		return err
+	}
+	return q.AddPostTags(ctx, database.AddPostTagsParams{PostID: postID, Names: names})
+}
+
+// TagProgram adds the tags, normalized with NormalizeTags, to a program.
+func (s *TagService) TagProgram(ctx context.Context, programID uuid.UUID, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	if err := s.DB.CreateTags(ctx, names); err != nil {
+		return err
+	}
+	return s.DB.AddProgramTags(ctx, database.AddProgramTagsParams{ProgramID: programID, Names: names})
+}
+
+// Trending returns the tags used by the most people in public posts and
+// programs created within the window before now. The window is capped at
+// MaxTrendingWindow.
+func (s *TagService) Trending(ctx context.Context, viewerID uuid.NullUUID, window time.Duration) ([]database.GetTrendingTagsRow, error) {
+	window = min(window, MaxTrendingWindow)
+	return s.DB.GetTrendingTags(ctx, database.GetTrendingTagsParams{
+		Since:    time.Now().Add(-window),
+		ViewerID: viewerID,
+		MaxTags:  trendingTagsLimit,
+	})
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"no tags here", nil},
+		{"#Powerlifting day", []string{"powerlifting"}},
+		{"new block: #hypertrophy, #beginner!", []string{"hypertrophy", "beginner"}},
+		{"#squat #Squat #SQUAT", []string{"squat"}},
+		{"set #1 of #5x5", []string{"5x5"}},
+		{"https://example.com/page#section and a&#39;b", nil},
+		{"##double #", nil},
+		{"#жим", []string{"жим"}},
+		{"#" + strings.Repeat("a", 51), nil},
+	}
+	for _, tt := range tests {
+		if got := parseTags(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("parseTags(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	many := ""
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+		many += " #" + name
+	}
+	if got := parseTags(many); len(got) != maxTags {
+		t.Errorf("got %d tags, want them capped at %d", len(got), maxTags)
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags([]string{"#Powerlifting", "beginner", "powerlifting"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"powerlifting", "beginner"}; !slices.Equal(got, want) {
+		t.Errorf("NormalizeTags = %q, want %q", got, want)
+	}
+	for _, tag := range []string{"", "#", "two words", "123", "full-body"} {
+		if _, err := NormalizeTags([]string{tag}); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("NormalizeTags(%q) error = %v, want ErrInvalidTag", tag, err)
+		}
+	}
+	tags := strings.Split("a b c d e f g h i j k", " ")
+	if _, err := NormalizeTags(tags); !errors.Is(err, ErrTooManyTags) {
+		t.Errorf("%d tags error = %v, want ErrTooManyTags", len(tags), err)
+	}
+}
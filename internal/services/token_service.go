@@ -5,6 +5,8 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +14,19 @@ import (
 	"github.com/sssseraphim/fitterBy/internal/database"
 )
 
+const EventTokenTheftDetected = "token_theft_detected"
+
+var (
+	ErrTokenReused     = errors.New("refresh token reuse detected")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// ClientInfo describes the device a refresh token was issued to.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
 type TokenService struct {
 	DB        *database.Queries
 	JWTConfig *auth.JWTConfig
@@ -23,7 +38,12 @@ func NewTokenService(db *database.Queries, jwtConfig *auth.JWTConfig) *TokenServ
 		JWTConfig: jwtConfig}
 }
 
-func (s *TokenService) GenerateTokens(ctx context.Context, userID, userType, email string) (string, string, error) {
+// GenerateTokens issues a fresh token pair that starts a new refresh token family.
+func (s *TokenService) GenerateTokens(ctx context.Context, userID, userType, email string, client ClientInfo) (string, string, error) {
+	return s.generateTokens(ctx, userID, userType, email, uuid.New(), client)
+}
+
+func (s *TokenService) generateTokens(ctx context.Context, userID, userType, email string, familyID uuid.UUID, client ClientInfo) (string, string, error) {
 	accessToken, err := s.JWTConfig.GenerateAccessToken(userID, userType, email)
 	if err != nil {
 		return "", "", err
@@ -32,13 +52,14 @@ func (s *TokenService) GenerateTokens(ctx context.Context, userID, userType, ema
 	if err != nil {
 		return "", "", err
 	}
-	hash := sha256.Sum256([]byte(refreshToken))
-	refreshTokenHash := hex.EncodeToString(hash[:])
 	expires_at := time.Now().Add(s.JWTConfig.RefreshTokenExpiry)
 	_, err = s.DB.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
 		UserID:    uuid.MustParse(userID),
-		TokenHash: refreshTokenHash,
+		TokenHash: hashToken(refreshToken),
 		ExpiresAt: expires_at,
+		FamilyID:  familyID,
+		UserAgent: client.UserAgent,
+		IpAddress: client.IPAddress,
 	})
 	if err != nil {
 		return "", "", err
@@ -47,15 +68,19 @@ func (s *TokenService) GenerateTokens(ctx context.Context, userID, userType, ema
 	return accessToken, refreshToken, nil
 }
 
-func (s *TokenService) ValidateAndRefreshTokens(ctx context.Context, refreshToken string) (string, string, error) {
-	hash := sha256.Sum256([]byte(refreshToken))
-	refreshTokenHash := hex.EncodeToString(hash[:])
-
-	storedToken, err := s.DB.GetRefreshToken(ctx, refreshTokenHash)
+// ValidateAndRefreshTokens rotates a refresh token. The used token is kept as
+// rotated so that presenting it again is detected as reuse, in which case every
+// refresh token of the user is revoked.
+func (s *TokenService) ValidateAndRefreshTokens(ctx context.Context, refreshToken string, client ClientInfo) (string, string, error) {
+	storedToken, err := s.DB.GetRefreshToken(ctx, hashToken(refreshToken))
 	if err != nil {
 		return "", "", err
 	}
 
+	if storedToken.RotatedAt.Valid {
+		return "", "", s.handleTokenReuse(ctx, storedToken, client)
+	}
+
 	if time.Now().After(storedToken.ExpiresAt) {
 		s.DB.DeleteRefreshToken(ctx, storedToken.TokenHash)
 		return "", "", errors.New("token expired")
@@ -69,19 +94,64 @@ func (s *TokenService) ValidateAndRefreshTokens(ctx context.Context, refreshToke
 		return "", "", errors.New("Invalid Token")
 	}
 
-	newAccessToken, newRefreshToken, err := s.GenerateTokens(ctx, claims.UserID, claims.UserType, claims.Email)
+	rotated, err := s.DB.RotateRefreshToken(ctx, storedToken.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if rotated == 0 {
+		// another request rotated this token between the lookup and now
+		return "", "", s.handleTokenReuse(ctx, storedToken, client)
+	}
+
+	// the role may have changed since the refresh token was issued
+	user, err := s.DB.GetUser(ctx, storedToken.UserID)
 	if err != nil {
 		return "", "", err
 	}
-	s.DB.DeleteRefreshToken(ctx, storedToken.TokenHash)
-	return newAccessToken, newRefreshToken, nil
+	return s.generateTokens(ctx, claims.UserID, user.Role, user.Email, storedToken.FamilyID, client)
+}
 
+func (s *TokenService) handleTokenReuse(ctx context.Context, storedToken database.RefreshToken, client ClientInfo) error {
+	log.Printf("refresh token reuse detected for user %s, family %s", storedToken.UserID, storedToken.FamilyID)
+	if err := s.DB.DeleteUserRefreshToken(ctx, storedToken.UserID); err != nil {
+		return err
+	}
+	err := s.DB.CreateAuthEvent(ctx, database.CreateAuthEventParams{
+		UserID:    uuid.NullUUID{UUID: storedToken.UserID, Valid: true},
+		EventType: EventTokenTheftDetected,
+		Details:   fmt.Sprintf("rotated refresh token %s of family %s was used again", storedToken.ID, storedToken.FamilyID),
+		IpAddress: client.IPAddress,
+	})
+	if err != nil {
+		return err
+	}
+	return ErrTokenReused
 }
 
 func (s *TokenService) RevokeUserTokens(ctx context.Context, userID string) error {
 	return s.DB.DeleteUserRefreshToken(ctx, uuid.MustParse(userID))
 }
 
+// RevokeSession revokes every refresh token of one session (token family) of the user.
+func (s *TokenService) RevokeSession(ctx context.Context, userID string, sessionID uuid.UUID) error {
+	deleted, err := s.DB.DeleteUserSession(ctx, database.DeleteUserSessionParams{
+		FamilyID: sessionID,
+		UserID:   uuid.MustParse(userID),
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
 func (s *TokenService) CleanExpiredTokens(ctx context.Context) error {
 	return s.DB.CleanExpiredRefreshTokens(ctx)
 }
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
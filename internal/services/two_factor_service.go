@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sssseraphim/fitterBy/internal/auth"
+	"github.com/sssseraphim/fitterBy/internal/database"
+)
+
+const (
+	recoveryCodeCount       = 10
+	maxMFAChallengeAttempts = 5
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication not enrolled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidMFAChallenge  = errors.New("mfa challenge invalid or expired")
+)
+
+type TwoFactorService struct {
+	DB              *database.Queries
+	Issuer          string
+	ChallengeExpiry time.Duration
+}
+
+func NewTwoFactorService(db *database.Queries, issuer string, challengeExpiry time.Duration) *TwoFactorService {
+	return &TwoFactorService{
+		DB:              db,
+		Issuer:          issuer,
+		ChallengeExpiry: challengeExpiry,
+	}
+}
+
+// Enroll creates a new, still unconfirmed TOTP secret for the user and returns
+// it together with its otpauth:// URI.
+func (s *TwoFactorService) Enroll(ctx context.Context, userID uuid.UUID, email string) (string, string, error) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	created, err := s.DB.UpsertUserTOTP(ctx, database.UpsertUserTOTPParams{
+		UserID: userID,
+		Secret: secret,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if created == 0 {
+		return "", "", ErrTwoFactorEnabled
+	}
+	return secret, auth.TOTPURI(s.Issuer, email, secret), nil
+}
+
+// Confirm enables two-factor authentication once the user proves their
+// authenticator works. It returns fresh recovery codes, which are only stored hashed.
+func (s *TwoFactorService) Confirm(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	totp, err := s.DB.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if totp.ConfirmedAt.Valid {
+		return nil, ErrTwoFactorEnabled
+	}
+	if err := s.checkTOTP(ctx, totp, code); err != nil {
+		return nil, err
+	}
+	if err := s.DB.ConfirmUserTOTP(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.regenerateRecoveryCodes(ctx, userID)
+}
+
+// Disable turns two-factor authentication off, it requires a valid TOTP or recovery code.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	if err := s.VerifyCode(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := s.DB.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+		return err
+	}
+	return s.DB.DeleteUserTOTP(ctx, userID)
+}
+
+func (s *TwoFactorService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	totp, err := s.DB.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return totp.ConfirmedAt.Valid, nil
+}
+
+// VerifyCode accepts either a TOTP code or an unused recovery code of a user
+// with confirmed two-factor authentication.
+func (s *TwoFactorService) VerifyCode(ctx context.Context, userID uuid.UUID, code string) error {
+	totp, err := s.DB.GetUserTOTP(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if !totp.ConfirmedAt.Valid {
+		return ErrTwoFactorNotEnrolled
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == auth.TOTPDigits {
+		return s.checkTOTP(ctx, totp, code)
+	}
+	used, err := s.DB.UseRecoveryCode(ctx, database.UseRecoveryCodeParams{
+		UserID:   userID,
+		CodeHash: hashToken(normalizeRecoveryCode(code)),
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// CreateChallenge issues the short-lived token a user trades for real tokens
+// together with a second factor after a successful password check.
+func (s *TwoFactorService) CreateChallenge(ctx context.Context, userID uuid.UUID) (string, error) {
+	token, err := generateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.DB.CreateMFAChallenge(ctx, database.CreateMFAChallengeParams{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.ChallengeExpiry),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CompleteChallenge checks the code for the challenge and returns the user it
+// was issued for. A challenge can be completed once and survives only a few wrong codes.
+// ChallengeUser returns the user logging in with the challenge, so failed
+// codes can be counted against them.
+func (s *TwoFactorService) ChallengeUser(ctx context.Context, token string) (uuid.UUID, error) {
+	challenge, err := s.DB.GetMFAChallenge(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return challenge.UserID, nil
+}
+
+func (s *TwoFactorService) CompleteChallenge(ctx context.Context, token, code string) (uuid.UUID, error) {
+	challenge, err := s.DB.GetMFAChallenge(ctx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrInvalidMFAChallenge
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	attempts, err := s.DB.IncrementMFAChallengeAttempts(ctx, challenge.ID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if attempts > maxMFAChallengeAttempts {
+		s.DB.DeleteMFAChallenge(ctx, challenge.ID)
+		return uuid.Nil, ErrInvalidMFAChallenge
+	}
+	if err := s.VerifyCode(ctx, challenge.UserID, code); err != nil {
+		return uuid.Nil, err
+	}
+	if err := s.DB.DeleteMFAChallenge(ctx, challenge.ID); err != nil {
+		return uuid.Nil, err
+	}
+	return challenge.UserID, nil
+}
+
+func (s *TwoFactorService) checkTOTP(ctx context.Context, totp database.UserTotp, code string) error {
+	step, valid := auth.ValidateTOTP(totp.Secret, code, time.Now())
+	if !valid {
+		return ErrInvalidTwoFactorCode
+	}
+	// every code can be used once
+	used, err := s.DB.UseTOTPStep(ctx, database.UseTOTPStepParams{
+		UserID:       totp.UserID,
+		LastUsedStep: step,
+	})
+	if err != nil {
+		return err
+	}
+	if used == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) regenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if err := s.DB.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := base32.StdEncoding.EncodeToString(b)
+		code := strings.ToLower(raw[:4] + "-" + raw[4:])
+		err := s.DB.CreateRecoveryCode(ctx, database.CreateRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
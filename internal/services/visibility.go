@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// Visibility levels of profiles and posts.
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityPrivate   = "private"
+)
+
+var ErrInvalidVisibility = errors.New("visibility must be public, followers or private")
+
+// ValidVisibility reports whether v is one of the visibility levels.
+func ValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityFollowers || v == VisibilityPrivate
+}
+
+// VisibilityService decides who may see what. Every read of a post, and of
+// the comments and likes below it, goes through CanViewPost.
+type VisibilityService struct {
+	Profiles *ProfileService
+	Follows  *FollowService
+	Blocks   *BlockService
+}
+
+func NewVisibilityService(profiles *ProfileService, follows *FollowService, blocks *BlockService) *VisibilityService {
+	return &VisibilityService{Profiles: profiles, Follows: follows, Blocks: blocks}
+}
+
+// CanViewPost reports whether the viewer, who is not valid for anonymous
+// requests, may see a post of the author with the given visibility.
+func (s *VisibilityService) CanViewPost(ctx context.Context, viewerID uuid.NullUUID, authorID uuid.UUID, visibility string) (bool, error) {
+	if viewerID.Valid && viewerID.UUID == authorID {
+		return true, nil
+	}
+	blocked, err := s.Blocks.IsBlocked(ctx, viewerID, authorID)
+	if err != nil || blocked {
+		return false, err
+	}
+	if visibility == VisibilityPrivate {
+		return false, nil
+	}
+	settings, err := s.Profiles.Settings(ctx, authorID)
+	if err != nil {
+		return false, err
+	}
+	following := false
+	if viewerID.Valid && (visibility == VisibilityFollowers || settings.ProfileVisibility == VisibilityPrivate) {
+		following, err = s.Follows.IsFollowing(ctx, viewerID.UUID, authorID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return postVisible(visibility, settings.ProfileVisibility == VisibilityPrivate, following), nil
+}
+
+// postVisible applies the visibility rules for viewers other than the author.
+// Public posts of private accounts are only shown to followers.
+func postVisible(visibility string, privateAccount, following bool) bool {
+	switch visibility {
+	case VisibilityPublic:
+		return !privateAccount || following
+	case VisibilityFollowers:
+		return following
+	default:
+		return false
+	}
+}
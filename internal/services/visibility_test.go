@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestPostVisible(t *testing.T) {
+	tests := []struct {
+		visibility     string
+		privateAccount bool
+		following      bool
+		want           bool
+	}{
+		{VisibilityPublic, false, false, true},
+		{VisibilityPublic, true, false, false},
+		{VisibilityPublic, true, true, true},
+		{VisibilityFollowers, false, false, false},
+		{VisibilityFollowers, false, true, true},
+		{VisibilityPrivate, false, true, false},
+		{"unknown", false, true, false},
+	}
+	for _, tt := range tests {
+		if got := postVisible(tt.visibility, tt.privateAccount, tt.following); got != tt.want {
+			t.Errorf("postVisible(%q, private=%v, following=%v) = %v, want %v", tt.visibility, tt.privateAccount, tt.following, got, tt.want)
+		}
+	}
+}
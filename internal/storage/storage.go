@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps uploaded files. Keys are relative paths like "ab12.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL is where clients download the file from.
+	URL(key string) string
+}
+
+// validKey reports whether key is a clean relative path that stays inside
+// the storage root.
+func validKey(key string) bool {
+	return key != "" && !strings.HasPrefix(key, "/") && !strings.Contains(key, "\\") &&
+		path.Clean(key) == key && key != "." && !strings.HasPrefix(key, "../")
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,8 +17,11 @@ import (
 	"github.com/sssseraphim/fitterBy/internal/auth"
 	"github.com/sssseraphim/fitterBy/internal/database"
 	"github.com/sssseraphim/fitterBy/internal/handlers"
+	"github.com/sssseraphim/fitterBy/internal/mail"
 	"github.com/sssseraphim/fitterBy/internal/middleware"
+	"github.com/sssseraphim/fitterBy/internal/oauth"
 	"github.com/sssseraphim/fitterBy/internal/services"
+	"github.com/sssseraphim/fitterBy/internal/storage"
 )
 
 type apiConfig struct {
@@ -43,68 +49,305 @@ func main() {
 		AccessTokenExpiry:  60 * time.Minute,
 		RefreshTokenExpiry: 7 * 24 * time.Hour,
 	}
+	if keysDir := os.Getenv("JWT_KEYS_DIR"); keysDir != "" {
+		jwtConfig.KeySet, err = auth.LoadKeySet(keysDir, os.Getenv("JWT_ACTIVE_KEY_ID"))
+		if err != nil {
+			log.Fatalf("Failed to load JWT keys: %v", err)
+		}
+		jwtConfig.AcceptLegacyHS256 = os.Getenv("JWT_ACCEPT_LEGACY_HS256") == "true"
+	}
+
+	var mailer mail.Mailer
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		mailer = mail.NewSMTPMailer(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("MAIL_FROM"))
+	default:
+		mailer = mail.NewLogMailer(os.Getenv("MAIL_LOG_FILE"), os.Getenv("MAIL_FROM"))
+	}
+	baseURL := os.Getenv("APP_BASE_URL")
+
+	emailVerificationService := services.NewEmailVerificationService(cfg.dbQueries, mailer, baseURL, 48*time.Hour)
+	// unverified users can't post or publish programs when REQUIRE_VERIFIED_EMAIL is set
+	requireVerified := func(next http.Handler) http.Handler { return next }
+	if os.Getenv("REQUIRE_VERIFIED_EMAIL") == "true" {
+		requireVerified = middleware.RequireVerifiedEmail(emailVerificationService)
+	}
+
+	var loginAttemptStore services.LoginAttemptStore
+	if os.Getenv("LOGIN_ATTEMPT_STORE") == "memory" {
+		loginAttemptStore = services.NewMemoryLoginAttemptStore()
+	} else {
+		loginAttemptStore = services.NewPostgresLoginAttemptStore(cfg.dbQueries)
+	}
+
+	loginGuard := services.NewLoginGuard(loginAttemptStore, cfg.dbQueries)
+	twoFactorService := services.NewTwoFactorService(cfg.dbQueries, "fitterBy", 5*time.Minute)
+	apiKeyService := services.NewAPIKeyService(cfg.dbQueries)
+	authMiddleware := middleware.AuthMiddleware(jwtConfig, apiKeyService)
+	scope := middleware.AllowScope
 
 	authHandler := &handlers.AuthHandler{
-		DB:           cfg.dbQueries,
-		TokenService: *services.NewTokenService(cfg.dbQueries, jwtConfig),
-		JWTConfig:    jwtConfig,
+		DB:                       cfg.dbQueries,
+		TokenService:             *services.NewTokenService(cfg.dbQueries, jwtConfig),
+		JWTConfig:                jwtConfig,
+		PasswordResetService:     services.NewPasswordResetService(cfg.dbQueries, mailer, baseURL, time.Hour),
+		EmailVerificationService: emailVerificationService,
+		TwoFactorService:         twoFactorService,
+		LoginGuard:               loginGuard,
+		APIKeyService:            apiKeyService,
+		OAuthService:             services.NewOAuthService(cfg.dbQueries, 10*time.Minute, oauthProvidersFromEnv(baseURL)...),
+		Cookies: handlers.CookieConfig{
+			Enabled: os.Getenv("COOKIE_SESSIONS") == "true",
+			Secure:  os.Getenv("COOKIE_SECURE") == "true",
+		},
 	}
 	mux := http.NewServeMux()
 	// Serve static files (CSS, JS, images)
 	mux.HandleFunc("/", serveTemplate("index.html"))
 
 	// Auth endpoints
+	mux.HandleFunc("GET /.well-known/jwks.json", authHandler.HandleJWKS)
 	mux.HandleFunc("POST /api/auth/signup", authHandler.HandleSignup)
 	mux.HandleFunc("POST /api/auth/login", authHandler.HandleLogin)
+	mux.HandleFunc("POST /api/auth/login/mfa", authHandler.HandleLoginMFA)
+	mux.HandleFunc("POST /api/auth/refresh", authHandler.RefreshTokenHandler)
+	mux.HandleFunc("POST /api/auth/password/forgot", authHandler.HandleForgotPassword)
+	mux.HandleFunc("POST /api/auth/password/reset", authHandler.HandleResetPassword)
+	mux.HandleFunc("GET /reset-password", serveTemplate("reset_password.html"))
+	mux.HandleFunc("GET /api/auth/verify", authHandler.HandleVerifyEmail)
+	mux.Handle("POST /api/auth/verify/resend", authMiddleware(http.HandlerFunc(authHandler.HandleResendVerification)))
+	mux.HandleFunc("GET /api/auth/oauth", authHandler.HandleGetOAuthProviders)
+	mux.HandleFunc("GET /api/auth/oauth/{provider}", authHandler.HandleOAuthLogin)
+	mux.HandleFunc("GET /api/auth/oauth/{provider}/callback", authHandler.HandleOAuthCallback)
+	mux.Handle("POST /api/auth/logout", authMiddleware(http.HandlerFunc(authHandler.LogoutHandler)))
 
+	mediaStorage := mediaStorageFromEnv(baseURL)
+	if local, ok := mediaStorage.(*storage.LocalStorage); ok {
+		mux.Handle("GET /media/", http.StripPrefix("/media/", local.Handler()))
+	}
+	mediaService := services.NewMediaService(cfg.dbQueries, mediaStorage, mediaMaxUploadBytes())
+	go mediaService.RunCleanup(context.Background(), time.Hour)
+	accountService := services.NewAccountService(cfg.dbQueries, db, mediaService, accountDeletionGracePeriod())
+	go accountService.RunPurger(context.Background(), time.Hour)
+	profileService := services.NewProfileService(cfg.dbQueries, mediaService)
+	blockService := services.NewBlockService(cfg.dbQueries)
+	followService := services.NewFollowService(cfg.dbQueries, profileService)
+	optionalAuth := middleware.OptionalAuth(authMiddleware)
 	userHandler := &handlers.UserHandler{
-		DB: cfg.dbQueries,
+		DB:                       cfg.dbQueries,
+		AccountService:           accountService,
+		ProfileService:           profileService,
+		EmailVerificationService: emailVerificationService,
+		FollowService:            followService,
+		BlockService:             blockService,
+		LoginGuard:               loginGuard,
+		TwoFactorService:         twoFactorService,
 	}
 	// User endpoints
-	mux.HandleFunc("GET /api/users/{user_id}", userHandler.HandleGetUser)
-	mux.Handle("GET /api/me", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(userHandler.HandleGetCurrentUser)))
-	mux.Handle("PATCH /api/me/bio", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(userHandler.HandlerUpdateBio)))
-	mux.Handle("POST /api/users/follow", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(userHandler.HandlerFollow)))
-	mux.Handle("GET /api/users/follow", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(userHandler.HandlerGetFollowedUsers)))
+	mux.Handle("GET /api/users/{user_id}", optionalAuth(http.HandlerFunc(userHandler.HandleGetUser)))
+	mux.Handle("GET /api/me", scope(auth.ScopeProfileRead)(authMiddleware(http.HandlerFunc(userHandler.HandleGetCurrentUser))))
+	mux.Handle("PATCH /api/me", authMiddleware(http.HandlerFunc(userHandler.HandleUpdateProfile)))
+	mux.Handle("PUT /api/me/password", authMiddleware(http.HandlerFunc(authHandler.HandleChangePassword)))
+	mux.Handle("DELETE /api/me", authMiddleware(http.HandlerFunc(userHandler.HandleDeleteAccount)))
+	mux.Handle("POST /api/me/restore", authMiddleware(http.HandlerFunc(userHandler.HandleRestoreAccount)))
+	mux.Handle("GET /api/me/export", authMiddleware(http.HandlerFunc(userHandler.HandleExportAccount)))
+	mux.Handle("GET /api/me/sessions", authMiddleware(http.HandlerFunc(authHandler.HandleGetSessions)))
+	mux.Handle("DELETE /api/me/sessions/{session_id}", authMiddleware(http.HandlerFunc(authHandler.HandleRevokeSession)))
+	mux.Handle("POST /api/me/2fa", authMiddleware(http.HandlerFunc(authHandler.HandleEnrollTwoFactor)))
+	mux.Handle("POST /api/me/2fa/confirm", authMiddleware(http.HandlerFunc(authHandler.HandleConfirmTwoFactor)))
+	mux.Handle("DELETE /api/me/2fa", authMiddleware(http.HandlerFunc(authHandler.HandleDisableTwoFactor)))
+	mux.Handle("POST /api/me/api-keys", authMiddleware(http.HandlerFunc(authHandler.HandleCreateAPIKey)))
+	mux.Handle("GET /api/me/api-keys", authMiddleware(http.HandlerFunc(authHandler.HandleGetAPIKeys)))
+	mux.Handle("DELETE /api/me/api-keys/{key_id}", authMiddleware(http.HandlerFunc(authHandler.HandleDeleteAPIKey)))
+	mux.Handle("GET /api/me/identities", authMiddleware(http.HandlerFunc(authHandler.HandleGetIdentities)))
+	mux.Handle("POST /api/me/identities/{provider}", authMiddleware(http.HandlerFunc(authHandler.HandleLinkIdentity)))
+	mux.Handle("DELETE /api/me/identities/{provider}", authMiddleware(http.HandlerFunc(authHandler.HandleUnlinkIdentity)))
+	mux.Handle("PATCH /api/me/bio", authMiddleware(http.HandlerFunc(userHandler.HandlerUpdateBio)))
+	mux.Handle("POST /api/users/follow", authMiddleware(http.HandlerFunc(userHandler.HandlerFollow)))
+	mux.Handle("GET /api/users/follow", authMiddleware(http.HandlerFunc(userHandler.HandlerGetFollowedUsers)))
+	mux.Handle("DELETE /api/users/follow/{user_id}", authMiddleware(http.HandlerFunc(userHandler.HandleUnfollow)))
+	mux.Handle("GET /api/users/{user_id}/followers", authMiddleware(http.HandlerFunc(userHandler.HandleGetFollowers)))
+	mux.Handle("GET /api/users/{user_id}/following", authMiddleware(http.HandlerFunc(userHandler.HandleGetFollowing)))
+	mux.Handle("GET /api/me/blocks", authMiddleware(http.HandlerFunc(userHandler.HandleGetBlockedUsers)))
+	mux.Handle("POST /api/me/blocks/{user_id}", authMiddleware(http.HandlerFunc(userHandler.HandleBlockUser)))
+	mux.Handle("DELETE /api/me/blocks/{user_id}", authMiddleware(http.HandlerFunc(userHandler.HandleUnblockUser)))
+	mux.Handle("GET /api/me/mutes", authMiddleware(http.HandlerFunc(userHandler.HandleGetMutedUsers)))
+	mux.Handle("POST /api/me/mutes/{user_id}", authMiddleware(http.HandlerFunc(userHandler.HandleMuteUser)))
+	mux.Handle("DELETE /api/me/mutes/{user_id}", authMiddleware(http.HandlerFunc(userHandler.HandleUnmuteUser)))
+	mux.Handle("GET /api/me/follow-requests", authMiddleware(http.HandlerFunc(userHandler.HandleGetFollowRequests)))
+	mux.Handle("POST /api/me/follow-requests/{user_id}/approve", authMiddleware(http.HandlerFunc(userHandler.HandleApproveFollowRequest)))
+	mux.Handle("POST /api/me/follow-requests/{user_id}/reject", authMiddleware(http.HandlerFunc(userHandler.HandleRejectFollowRequest)))
+
+	// Admin endpoints
+	requireAdmin := middleware.RequireRole(auth.RoleAdmin)
+	mux.Handle("GET /api/admin/auth-events", authMiddleware(requireAdmin(http.HandlerFunc(authHandler.HandleGetAuthEvents))))
+	mux.Handle("PATCH /api/admin/users/{user_id}/role", authMiddleware(requireAdmin(http.HandlerFunc(userHandler.HandleUpdateUserRole))))
+
+	mediaHandler := &handlers.MediaHandler{
+		MediaService: mediaService,
+	}
+	// Media endpoints
+	mux.Handle("POST /api/media", scope(auth.ScopePostsWrite)(authMiddleware(requireVerified(http.HandlerFunc(mediaHandler.HandleUploadMedia)))))
 
+	visibilityService := services.NewVisibilityService(profileService, followService, blockService)
+	attachmentService := services.NewAttachmentService(cfg.dbQueries, visibilityService)
 	postHandler := &handlers.PostHandler{
-		DB: cfg.dbQueries,
+		DB:                  cfg.dbQueries,
+		VisibilityService:   visibilityService,
+		PostService:         services.NewPostService(cfg.dbQueries, db),
+		NotificationService: services.NewNotificationService(cfg.dbQueries, visibilityService),
+		MediaService:        mediaService,
+		FeedService:         services.NewFeedService(cfg.dbQueries, services.NewWeightedRanker()),
+		AttachmentService:   attachmentService,
 	}
 	// Posts endpoints
-	mux.HandleFunc("GET /api/posts/{post_id}", postHandler.HandleGetPost)
-	mux.Handle("GET /api/posts/followed", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(postHandler.HandleGetFollowedPosts)))
-	mux.Handle("POST /api/posts", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(postHandler.HandleCreatePost)))
-	mux.Handle("POST /api/posts/like", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(postHandler.HandlerLikePost)))
-	mux.Handle("POST /api/posts/comments", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(postHandler.HandlerComment)))
-	mux.HandleFunc("GET /api/posts/comments", postHandler.HandlerGetComments)
+	mux.Handle("GET /api/posts/{post_id}", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(postHandler.HandleGetPost))))
+	mux.Handle("GET /api/posts/followed", scope(auth.ScopePostsRead)(authMiddleware(http.HandlerFunc(postHandler.HandleGetFollowedPosts))))
+	mux.Handle("GET /api/posts/feed", scope(auth.ScopePostsRead)(authMiddleware(http.HandlerFunc(postHandler.HandleGetHomeFeed))))
+	mux.Handle("GET /api/posts/explore", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(postHandler.HandleGetExplore))))
+	mux.Handle("POST /api/posts", scope(auth.ScopePostsWrite)(authMiddleware(requireVerified(http.HandlerFunc(postHandler.HandleCreatePost)))))
+	mux.Handle("POST /api/posts/like", scope(auth.ScopePostsWrite)(authMiddleware(http.HandlerFunc(postHandler.HandlerLikePost))))
+	mux.Handle("POST /api/posts/comments", scope(auth.ScopePostsWrite)(authMiddleware(requireVerified(http.HandlerFunc(postHandler.HandlerComment)))))
+	mux.Handle("GET /api/posts/comments", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(postHandler.HandlerGetComments))))
+	mux.Handle("POST /api/posts/comments/like", scope(auth.ScopePostsWrite)(authMiddleware(http.HandlerFunc(postHandler.HandlerLikeComment))))
+	mux.Handle("PATCH /api/posts/{post_id}", scope(auth.ScopePostsWrite)(authMiddleware(http.HandlerFunc(postHandler.HandleUpdatePost))))
+	mux.Handle("DELETE /api/posts/{post_id}", scope(auth.ScopePostsWrite)(authMiddleware(http.HandlerFunc(postHandler.HandleDeletePost))))
+	mux.Handle("GET /api/posts/{post_id}/revisions", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(postHandler.HandleGetPostRevisions))))
+	mux.Handle("PATCH /api/posts/comments/{comment_id}", scope(auth.ScopePostsWrite)(authMiddleware(http.HandlerFunc(postHandler.HandleUpdateComment))))
+	mux.Handle("DELETE /api/posts/comments/{comment_id}", scope(auth.ScopePostsWrite)(authMiddleware(http.HandlerFunc(postHandler.HandleDeleteComment))))
+	mux.Handle("GET /api/posts/comments/{comment_id}/replies", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(postHandler.HandleGetCommentReplies))))
+	mux.Handle("GET /api/posts/comments/{comment_id}/revisions", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(postHandler.HandleGetCommentRevisions))))
 	log.Println(" Servin from  http://localhost:8080/")
 
-	programHandler := &handlers.ProgramHandler{
+	notificationHandler := &handlers.NotificationHandler{
 		DB: cfg.dbQueries,
 	}
+	mux.Handle("GET /api/me/notifications", authMiddleware(http.HandlerFunc(notificationHandler.HandleGetNotifications)))
+	mux.Handle("POST /api/me/notifications/read", authMiddleware(http.HandlerFunc(notificationHandler.HandleMarkNotificationsRead)))
+
+	tagService := services.NewTagService(cfg.dbQueries)
+	programHandler := &handlers.ProgramHandler{
+		DB:                cfg.dbQueries,
+		TagService:        tagService,
+		MediaService:      mediaService,
+		VisibilityService: visibilityService,
+	}
 	// Programs endpoints
-	mux.Handle("POST /api/exercises", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(programHandler.HandleCreateExercise)))
+	mux.Handle("POST /api/exercises", scope(auth.ScopeProgramsWrite)(authMiddleware(http.HandlerFunc(programHandler.HandleCreateExercise))))
 	mux.HandleFunc("GET /api/exercises", programHandler.HandleGetExercises)
 	mux.HandleFunc("GET /api/exercises/{exercise_id}", programHandler.HandleGetExerciseById)
-	mux.Handle("POST /api/programs", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(programHandler.HandleCreateProgram)))
-	mux.HandleFunc("GET /api/programs", programHandler.HandleGetPrograms)
-	mux.HandleFunc("GET /api/programs/{program_id}", programHandler.HandleGetProgram)
-	mux.Handle("POST /api/programs/{program_id}/subscribe", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(programHandler.HandleSubscribeToProgram)))
-	mux.Handle("GET /api/users/me/programs", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(programHandler.HandleGetSubscribedPrograms)))
+	mux.Handle("POST /api/programs", scope(auth.ScopeProgramsWrite)(authMiddleware(requireVerified(http.HandlerFunc(programHandler.HandleCreateProgram)))))
+	mux.Handle("GET /api/programs", scope(auth.ScopeProgramsRead)(optionalAuth(http.HandlerFunc(programHandler.HandleGetPrograms))))
+	mux.Handle("GET /api/programs/{program_id}", scope(auth.ScopeProgramsRead)(optionalAuth(http.HandlerFunc(programHandler.HandleGetProgram))))
+	mux.Handle("POST /api/programs/{program_id}/subscribe", scope(auth.ScopeProgramsWrite)(authMiddleware(http.HandlerFunc(programHandler.HandleSubscribeToProgram))))
+	mux.Handle("GET /api/users/me/programs", scope(auth.ScopeProgramsRead)(authMiddleware(http.HandlerFunc(programHandler.HandleGetSubscribedPrograms))))
+
+	tagHandler := &handlers.TagHandler{
+		DB:                cfg.dbQueries,
+		TagService:        tagService,
+		AttachmentService: attachmentService,
+	}
+	// Tags endpoints
+	mux.Handle("GET /api/tags/trending", optionalAuth(http.HandlerFunc(tagHandler.HandleGetTrendingTags)))
+	mux.Handle("GET /api/tags/{tag}/posts", scope(auth.ScopePostsRead)(optionalAuth(http.HandlerFunc(tagHandler.HandleGetTagPosts))))
+	mux.Handle("GET /api/tags/{tag}/programs", scope(auth.ScopeProgramsRead)(optionalAuth(http.HandlerFunc(tagHandler.HandleGetTagPrograms))))
 
 	workoutHandler := &handlers.WorkoutHandler{
 		DB: cfg.dbQueries,
 	}
 	// Workouts endpoints
-	mux.Handle("POST /api/workouts", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(workoutHandler.HandleCreateWorkout)))
-	mux.Handle("GET /api/users/me/workouts", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(workoutHandler.HandleGetMyWorkouts)))
-	mux.Handle("GET /api/workouts/{workout_id}", middleware.AuthMiddleware(jwtConfig)(http.HandlerFunc(workoutHandler.HandleGetWorkout)))
+	mux.Handle("POST /api/workouts", scope(auth.ScopeWorkoutsWrite)(authMiddleware(http.HandlerFunc(workoutHandler.HandleCreateWorkout))))
+	mux.Handle("GET /api/users/me/workouts", scope(auth.ScopeWorkoutsRead)(authMiddleware(http.HandlerFunc(workoutHandler.HandleGetMyWorkouts))))
+	mux.Handle("GET /api/workouts/{workout_id}", scope(auth.ScopeWorkoutsRead)(authMiddleware(http.HandlerFunc(workoutHandler.HandleGetWorkout))))
 
-	server := &http.Server{Handler: mux, Addr: ":8080"}
+	oauthServerHandler := &handlers.OAuthServerHandler{
+		DB:      cfg.dbQueries,
+		Service: services.NewOAuthServerService(cfg.dbQueries, jwtConfig, time.Minute, 30*24*time.Hour),
+	}
+	// Third-party app endpoints
+	mux.HandleFunc("GET /oauth/authorize", serveTemplate("authorize.html"))
+	mux.HandleFunc("POST /oauth/token", oauthServerHandler.HandleToken)
+	mux.Handle("GET /api/oauth/authorize", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleGetAuthorization)))
+	mux.Handle("POST /api/oauth/authorize", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleAuthorize)))
+	mux.Handle("POST /api/oauth/apps", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleRegisterApp)))
+	mux.Handle("GET /api/oauth/apps", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleGetApps)))
+	mux.Handle("DELETE /api/oauth/apps/{client_id}", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleDeleteApp)))
+	mux.Handle("GET /api/me/apps", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleGetAuthorizedApps)))
+	mux.Handle("DELETE /api/me/apps/{client_id}", authMiddleware(http.HandlerFunc(oauthServerHandler.HandleRevokeAuthorizedApp)))
+
+	var handler http.Handler = mux
+	if header := os.Getenv("TRUSTED_PROXY_HEADER"); header != "" {
+		handler = middleware.ClientIP(header)(mux)
+	}
+	server := &http.Server{Handler: handler, Addr: ":8080"}
 	err = server.ListenAndServe()
 	fmt.Println(err)
 }
 
+// accountDeletionGracePeriod reads ACCOUNT_DELETION_GRACE_PERIOD, a Go
+// duration like 720h. Deleted accounts can be restored until it is over.
+func accountDeletionGracePeriod() time.Duration {
+	period, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"))
+	if err != nil || period < 0 {
+		return 30 * 24 * time.Hour
+	}
+	return period
+}
+
+// mediaStorageFromEnv picks where uploads are stored with MEDIA_DRIVER. "s3"
+// uses the S3_* settings, anything else the MEDIA_DIR directory, served by
+// this server under /media.
+func mediaStorageFromEnv(baseURL string) storage.Storage {
+	if os.Getenv("MEDIA_DRIVER") == "s3" {
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			PathStyle:       os.Getenv("S3_PATH_STYLE") == "true",
+			PublicURL:       os.Getenv("S3_PUBLIC_URL"),
+		})
+	}
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "media"
+	}
+	return storage.NewLocalStorage(dir, baseURL+"/media")
+}
+
+// mediaMaxUploadBytes reads MEDIA_MAX_UPLOAD_MB, the size limit of uploads.
+func mediaMaxUploadBytes() int64 {
+	mb, err := strconv.Atoi(os.Getenv("MEDIA_MAX_UPLOAD_MB"))
+	if err != nil || mb <= 0 {
+		return services.DefaultMaxUploadBytes
+	}
+	return int64(mb) << 20
+}
+
+// oauthProvidersFromEnv reads OAUTH_PROVIDERS, a comma separated list of
+// names, and OAUTH_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET and optional
+// _SCOPES for each of them.
+func oauthProvidersFromEnv(baseURL string) []oauth.Provider {
+	var providers []oauth.Provider
+	for _, name := range strings.Split(os.Getenv("OAUTH_PROVIDERS"), ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		providers = append(providers, oauth.NewOIDCProvider(oauth.OIDCConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  baseURL + "/api/auth/oauth/" + name + "/callback",
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}))
+	}
+	return providers
+}
+
 // serveTemplate serves HTML pages from templates folder
 func serveTemplate(templateName string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -119,6 +362,8 @@ func serveTemplate(templateName string) http.HandlerFunc {
 		}
 
 		w.Header().Set("Content-Type", "text/html")
+		// the consent screen must not be clickjacked
+		w.Header().Set("X-Frame-Options", "DENY")
 		w.Write(htmlBytes)
 	}
 }
@@ -0,0 +1,70 @@
+-- name: ScheduleUserDeletion :execrows
+UPDATE users
+SET deletion_scheduled_at = $2,
+updated_at = NOW()
+WHERE id = $1
+AND deletion_scheduled_at IS NULL;
+
+-- name: CancelUserDeletion :execrows
+UPDATE users
+SET deletion_scheduled_at = NULL,
+updated_at = NOW()
+WHERE id = $1
+AND deletion_scheduled_at IS NOT NULL;
+
+-- name: GetUsersDueForPurge :many
+SELECT id FROM users
+WHERE deletion_scheduled_at <= NOW()
+ORDER BY deletion_scheduled_at
+LIMIT $1;
+
+-- name: LockUserForPurge :one
+SELECT id FROM users
+WHERE id = $1
+AND deletion_scheduled_at <= NOW()
+FOR UPDATE;
+
+-- The tables below have no ON DELETE CASCADE to users, or reference the
+-- user's posts from other users' rows, so a purge clears them first.
+
+-- name: DeletePlainRepostsOfUser :exec
+-- Plain reposts of the user's posts would be left empty once repost_of is
+-- cleared, so they go with the posts. Quote posts stay.
+UPDATE posts
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE content = ''
+AND deleted_at IS NULL
+AND repost_of IN (SELECT id FROM posts originals WHERE originals.user_id = $1);
+
+-- name: PurgeUserCommentRevisions :exec
+DELETE FROM comment_revisions
+WHERE comment_id IN (SELECT id FROM posts_comments WHERE posts_comments.user_id = sqlc.arg('user_id')::uuid);
+
+-- name: DetachUserComments :exec
+-- Comments on other users' posts keep their place in the thread, so replies
+-- of other users stay, but lose their content and author.
+UPDATE posts_comments
+SET content = '', deleted_at = COALESCE(deleted_at, NOW()), user_id = NULL
+WHERE posts_comments.user_id = sqlc.arg('user_id')::uuid
+AND post_id NOT IN (SELECT id FROM posts WHERE posts.user_id = sqlc.arg('user_id'));
+
+-- name: PurgeUserPostsLikes :exec
+DELETE FROM posts_likes
+WHERE posts_likes.user_id = $1
+OR post_id IN (SELECT id FROM posts WHERE posts.user_id = $1);
+
+-- name: PurgeUserPostsComments :exec
+DELETE FROM posts_comments
+WHERE posts_comments.user_id = sqlc.arg('user_id')::uuid
+OR post_id IN (SELECT id FROM posts WHERE posts.user_id = sqlc.arg('user_id'));
+
+-- name: PurgeUserFollows :exec
+DELETE FROM user_follows
+WHERE follower_id = $1
+OR followed_id = $1;
+
+-- name: PurgeUserWorkouts :exec
+DELETE FROM workouts WHERE user_id = $1;
+
+-- name: PurgeUser :exec
+DELETE FROM users WHERE id = $1;
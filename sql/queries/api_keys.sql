@@ -0,0 +1,26 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, key_prefix, key_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetUserAPIKeys :many
+SELECT * FROM api_keys
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetAPIKeyByHash :one
+SELECT api_keys.*, users.email, users.role
+FROM api_keys
+INNER JOIN users ON api_keys.user_id = users.id
+WHERE api_keys.key_hash = $1
+AND users.deletion_scheduled_at IS NULL;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys
+SET last_used_at = NOW()
+WHERE id = $1;
+
+-- name: DeleteUserAPIKey :execrows
+DELETE FROM api_keys
+WHERE id = $1
+AND user_id = $2;
@@ -0,0 +1,59 @@
+-- Queries that list content for a viewer exclude users blocked in either
+-- direction with:
+--   NOT EXISTS (SELECT 1 FROM user_blocks
+--     WHERE (blocker_id = <viewer> AND blocked_id = <author>)
+--     OR (blocker_id = <author> AND blocked_id = <viewer>))
+
+-- name: BlockUser :execrows
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT (blocker_id, blocked_id) DO NOTHING;
+
+-- name: UnblockUser :execrows
+DELETE FROM user_blocks
+WHERE blocker_id = $1
+AND blocked_id = $2;
+
+-- name: IsBlockedEitherWay :one
+SELECT EXISTS (
+		SELECT 1 FROM user_blocks
+		WHERE (blocker_id = $1 AND blocked_id = $2)
+		OR (blocker_id = $2 AND blocked_id = $1)
+) AS blocked;
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM user_follows
+WHERE (follower_id = $1 AND followed_id = $2)
+OR (follower_id = $2 AND followed_id = $1);
+
+-- name: GetBlockedUsers :many
+SELECT users.id, users.name, COALESCE(user_settings.avatar_url, '')::text AS avatar_url, user_blocks.created_at
+FROM user_blocks
+INNER JOIN users ON users.id = user_blocks.blocked_id
+LEFT JOIN user_settings ON user_settings.user_id = users.id
+WHERE user_blocks.blocker_id = sqlc.arg('blocker_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (user_blocks.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY user_blocks.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: MuteUser :execrows
+INSERT INTO user_mutes (muter_id, muted_id)
+VALUES ($1, $2)
+ON CONFLICT (muter_id, muted_id) DO NOTHING;
+
+-- name: UnmuteUser :execrows
+DELETE FROM user_mutes
+WHERE muter_id = $1
+AND muted_id = $2;
+
+-- name: GetMutedUsers :many
+SELECT users.id, users.name, COALESCE(user_settings.avatar_url, '')::text AS avatar_url, user_mutes.created_at
+FROM user_mutes
+INNER JOIN users ON users.id = user_mutes.muted_id
+LEFT JOIN user_settings ON user_settings.user_id = users.id
+WHERE user_mutes.muter_id = sqlc.arg('muter_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (user_mutes.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY user_mutes.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
@@ -0,0 +1,39 @@
+-- name: GetCommentReplies :many
+-- The replies of each thread oldest first, after the cursor when one is
+-- given and at most per_thread of them.
+WITH replies AS (
+	SELECT posts_comments.*,
+			ROW_NUMBER() OVER (PARTITION BY posts_comments.root_comment_id ORDER BY posts_comments.created_at, posts_comments.id) AS position
+	FROM posts_comments
+	WHERE posts_comments.root_comment_id = ANY(sqlc.arg('root_ids')::uuid[])
+	AND NOT EXISTS (SELECT 1 FROM user_blocks
+			WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = posts_comments.user_id)
+			OR (blocker_id = posts_comments.user_id AND blocked_id = sqlc.narg('viewer_id')))
+	AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+		OR (posts_comments.created_at, posts_comments.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+)
+SELECT replies.id, replies.user_id, replies.post_id, replies.content, replies.created_at, replies.edited_at, replies.deleted_at, replies.parent_comment_id, replies.root_comment_id, replies.depth, users.name as commenter_name,
+		(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.comment_id = replies.id) as like_count,
+		EXISTS(SELECT 1 FROM comment_likes WHERE comment_likes.comment_id = replies.id AND comment_likes.user_id = sqlc.narg('viewer_id')) as user_liked
+FROM replies
+LEFT JOIN users ON replies.user_id = users.id
+WHERE replies.position <= sqlc.arg('per_thread')::int
+ORDER BY replies.created_at, replies.id;
+
+-- name: LikeComment :execrows
+INSERT INTO comment_likes(user_id, comment_id)
+VALUES (
+		$1,
+		$2
+		)
+ON CONFLICT DO NOTHING;
+
+-- name: UnlikeComment :execrows
+DELETE FROM comment_likes
+WHERE user_id = $1
+AND comment_id = $2;
+
+-- name: GetCommentLikeCount :one
+SELECT COUNT(*)
+FROM comment_likes
+WHERE comment_id = $1;
@@ -0,0 +1,12 @@
+-- name: CreateEmailVerificationToken :exec
+INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at, email_change)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ConsumeEmailVerificationToken :one
+DELETE FROM email_verification_tokens
+WHERE token_hash = $1
+AND expires_at > NOW()
+RETURNING user_id, email, email_change;
+
+-- name: DeleteUserEmailVerificationTokens :exec
+DELETE FROM email_verification_tokens WHERE user_id = $1;
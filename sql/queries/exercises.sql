@@ -7,7 +7,11 @@ WHERE exercises.id = $1;
 -- name: GetExercises :many
 SELECT exercises.*, users.name as author_name
 FROM exercises
-LEFT JOIN users ON exercises.user_id = users.id;
+LEFT JOIN users ON exercises.user_id = users.id
+WHERE sqlc.narg('cursor_created_at')::timestamp IS NULL
+OR (exercises.created_at, exercises.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+ORDER BY exercises.created_at DESC, exercises.id DESC
+LIMIT sqlc.arg('page_size');
 
 -- name: CreateExercise :one
 INSERT INTO exercises(name, user_id, description, media_urls)
@@ -0,0 +1,69 @@
+-- Every export query returns a JSON document with the column names as keys.
+
+-- name: ExportUserProfile :one
+SELECT row_to_json(u)::json AS data
+FROM (
+	SELECT id, created_at, updated_at, name, email, bio, premium, role, email_verified_at, deletion_scheduled_at
+	FROM users
+	WHERE id = $1
+) u;
+
+-- name: ExportUserPosts :one
+SELECT COALESCE(json_agg(p ORDER BY p.created_at), '[]')::json AS data
+FROM posts p
+WHERE p.user_id = $1;
+
+-- name: ExportUserComments :one
+SELECT COALESCE(json_agg(c ORDER BY c.created_at), '[]')::json AS data
+FROM posts_comments c
+WHERE c.user_id = sqlc.arg('user_id')::uuid;
+
+-- name: ExportUserLikes :one
+SELECT COALESCE(json_agg(l ORDER BY l.created_at), '[]')::json AS data
+FROM posts_likes l
+WHERE l.user_id = $1;
+
+-- name: ExportUserFollows :one
+SELECT COALESCE(json_agg(f ORDER BY f.created_at), '[]')::json AS data
+FROM user_follows f
+WHERE f.follower_id = $1
+OR f.followed_id = $1;
+
+-- name: ExportUserExercises :one
+SELECT COALESCE(json_agg(e ORDER BY e.created_at), '[]')::json AS data
+FROM exercises e
+WHERE e.user_id = $1;
+
+-- name: ExportUserPrograms :one
+SELECT COALESCE(json_agg(p ORDER BY p.created_at), '[]')::json AS data
+FROM (
+	SELECT programs.*, (
+		SELECT COALESCE(json_agg(d ORDER BY d.day_order), '[]')
+		FROM (
+			SELECT program_days.*, (
+				SELECT COALESCE(json_agg(pl ORDER BY pl.lift_order), '[]')
+				FROM program_lifts pl
+				WHERE pl.program_day_id = program_days.id
+			) AS lifts
+			FROM program_days
+			WHERE program_days.program_id = programs.id
+		) d
+	) AS days
+	FROM programs
+	WHERE programs.user_id = $1
+) p;
+
+-- name: ExportUserSubscriptions :one
+SELECT COALESCE(json_agg(s ORDER BY s.created_at), '[]')::json AS data
+FROM users_programs s
+WHERE s.user_id = $1;
+
+-- name: ExportUserWorkouts :one
+SELECT COALESCE(json_agg(w ORDER BY w.created_at), '[]')::json AS data
+FROM workouts w
+WHERE w.user_id = $1;
+
+-- name: ExportUserLifts :one
+SELECT COALESCE(json_agg(ul ORDER BY ul.created_at, ul.lift_order), '[]')::json AS data
+FROM users_lifts ul
+WHERE ul.user_id = $1;
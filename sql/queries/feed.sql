@@ -0,0 +1,102 @@
+-- name: GetHomeFeedCandidates :many
+-- Feed candidates carry the signals rankers score them by: the viewer's
+-- likes and comments on the author's posts, the post tags the viewer is into
+-- through posts they wrote or liked and programs they do, and whether the
+-- author wrote one of those programs. The home feed has posts of followed
+-- users and public posts of the authors of programs the viewer does. Likes
+-- and comments only count up to until, so a feed ranks the same on every page.
+SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
+		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id
+			AND posts_likes.created_at <= sqlc.arg('until')::timestamp) as like_count,
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id
+			AND posts_comments.created_at <= sqlc.arg('until')::timestamp
+			AND (posts_comments.deleted_at IS NULL OR posts_comments.deleted_at > sqlc.arg('until')::timestamp)) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = sqlc.arg('viewer_id')) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count,
+		EXISTS(SELECT 1 FROM user_follows
+			WHERE follower_id = sqlc.arg('viewer_id') AND followed_id = posts.user_id AND status = 'accepted') as following,
+		EXISTS(SELECT 1 FROM users_programs INNER JOIN programs ON users_programs.program_id = programs.id
+			WHERE users_programs.user_id = sqlc.arg('viewer_id') AND programs.user_id = posts.user_id) as from_program,
+		((SELECT COUNT(*) FROM posts_likes INNER JOIN posts liked ON posts_likes.post_id = liked.id
+			WHERE posts_likes.user_id = sqlc.arg('viewer_id') AND liked.user_id = posts.user_id
+			AND posts_likes.created_at <= sqlc.arg('until')::timestamp)
+		+ (SELECT COUNT(*) FROM posts_comments INNER JOIN posts commented ON posts_comments.post_id = commented.id
+			WHERE posts_comments.user_id = sqlc.arg('viewer_id') AND commented.user_id = posts.user_id
+			AND posts_comments.created_at <= sqlc.arg('until')::timestamp
+			AND (posts_comments.deleted_at IS NULL OR posts_comments.deleted_at > sqlc.arg('until')::timestamp)))::bigint as interactions,
+		(SELECT COUNT(*) FROM post_tags WHERE post_tags.post_id = posts.id AND post_tags.tag_id IN (
+			SELECT interest.tag_id FROM post_tags interest INNER JOIN posts_likes ON interest.post_id = posts_likes.post_id
+			WHERE posts_likes.user_id = sqlc.arg('viewer_id')
+			UNION SELECT interest.tag_id FROM post_tags interest INNER JOIN posts own ON interest.post_id = own.id
+			WHERE own.user_id = sqlc.arg('viewer_id')
+			UNION SELECT program_tags.tag_id FROM program_tags INNER JOIN users_programs ON program_tags.program_id = users_programs.program_id
+			WHERE users_programs.user_id = sqlc.arg('viewer_id'))) as tag_affinity
+FROM posts
+INNER JOIN users ON posts.user_id = users.id
+WHERE posts.created_at >= sqlc.arg('since')::timestamp
+AND posts.created_at <= sqlc.arg('until')::timestamp
+AND posts.deleted_at IS NULL
+AND posts.user_id <> sqlc.arg('viewer_id')
+AND users.deletion_scheduled_at IS NULL
+AND ((posts.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = sqlc.arg('viewer_id') AND followed_id = posts.user_id AND status = 'accepted'))
+	OR (posts.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = posts.user_id AND user_settings.profile_visibility = 'private')
+		AND EXISTS (SELECT 1 FROM users_programs INNER JOIN programs ON users_programs.program_id = programs.id
+		WHERE users_programs.user_id = sqlc.arg('viewer_id') AND programs.user_id = posts.user_id)))
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.arg('viewer_id') AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.arg('viewer_id') AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = sqlc.arg('viewer_id')))
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT sqlc.arg('max_candidates');
+
+-- name: GetExploreCandidates :many
+-- Explore has public posts of users the viewer doesn't follow yet, with the
+-- same signals as the home feed. Plain reposts are left out.
+SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
+		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id
+			AND posts_likes.created_at <= sqlc.arg('until')::timestamp) as like_count,
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id
+			AND posts_comments.created_at <= sqlc.arg('until')::timestamp
+			AND (posts_comments.deleted_at IS NULL OR posts_comments.deleted_at > sqlc.arg('until')::timestamp)) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = sqlc.narg('viewer_id')) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count,
+		false as following,
+		EXISTS(SELECT 1 FROM users_programs INNER JOIN programs ON users_programs.program_id = programs.id
+			WHERE users_programs.user_id = sqlc.narg('viewer_id') AND programs.user_id = posts.user_id) as from_program,
+		((SELECT COUNT(*) FROM posts_likes INNER JOIN posts liked ON posts_likes.post_id = liked.id
+			WHERE posts_likes.user_id = sqlc.narg('viewer_id') AND liked.user_id = posts.user_id
+			AND posts_likes.created_at <= sqlc.arg('until')::timestamp)
+		+ (SELECT COUNT(*) FROM posts_comments INNER JOIN posts commented ON posts_comments.post_id = commented.id
+			WHERE posts_comments.user_id = sqlc.narg('viewer_id') AND commented.user_id = posts.user_id
+			AND posts_comments.created_at <= sqlc.arg('until')::timestamp
+			AND (posts_comments.deleted_at IS NULL OR posts_comments.deleted_at > sqlc.arg('until')::timestamp)))::bigint as interactions,
+		(SELECT COUNT(*) FROM post_tags WHERE post_tags.post_id = posts.id AND post_tags.tag_id IN (
+			SELECT interest.tag_id FROM post_tags interest INNER JOIN posts_likes ON interest.post_id = posts_likes.post_id
+			WHERE posts_likes.user_id = sqlc.narg('viewer_id')
+			UNION SELECT interest.tag_id FROM post_tags interest INNER JOIN posts own ON interest.post_id = own.id
+			WHERE own.user_id = sqlc.narg('viewer_id')
+			UNION SELECT program_tags.tag_id FROM program_tags INNER JOIN users_programs ON program_tags.program_id = users_programs.program_id
+			WHERE users_programs.user_id = sqlc.narg('viewer_id'))) as tag_affinity
+FROM posts
+INNER JOIN users ON posts.user_id = users.id
+WHERE posts.created_at >= sqlc.arg('since')::timestamp
+AND posts.created_at <= sqlc.arg('until')::timestamp
+AND posts.deleted_at IS NULL
+AND posts.visibility = 'public'
+AND (posts.repost_of IS NULL OR posts.content <> '')
+AND posts.user_id IS DISTINCT FROM sqlc.narg('viewer_id')
+AND users.deletion_scheduled_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = posts.user_id AND user_settings.profile_visibility = 'private')
+AND NOT EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = sqlc.narg('viewer_id') AND followed_id = posts.user_id AND status = 'accepted')
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = sqlc.narg('viewer_id')))
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT sqlc.arg('max_candidates');
@@ -0,0 +1,85 @@
+-- name: FollowUser :execrows
+INSERT INTO user_follows(follower_id, followed_id, status)
+VALUES (
+		$1,
+		$2,
+		$3
+		)
+ON CONFLICT (follower_id, followed_id) DO NOTHING;
+
+-- name: GetFollowStatus :one
+SELECT status FROM user_follows
+WHERE follower_id = $1
+AND followed_id = $2;
+
+-- name: UnfollowUser :execrows
+DELETE FROM user_follows
+WHERE follower_id = $1
+AND followed_id = $2;
+
+-- name: GetFollowers :many
+SELECT users.id, users.name, COALESCE(user_settings.avatar_url, '')::text AS avatar_url, user_follows.created_at AS followed_at
+FROM user_follows
+INNER JOIN users ON users.id = user_follows.follower_id
+LEFT JOIN user_settings ON user_settings.user_id = users.id
+WHERE user_follows.followed_id = sqlc.arg('user_id')
+AND user_follows.status = 'accepted'
+AND users.deletion_scheduled_at IS NULL
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (user_follows.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY user_follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetFollowing :many
+SELECT users.id, users.name, COALESCE(user_settings.avatar_url, '')::text AS avatar_url, user_follows.created_at AS followed_at
+FROM user_follows
+INNER JOIN users ON users.id = user_follows.followed_id
+LEFT JOIN user_settings ON user_settings.user_id = users.id
+WHERE user_follows.follower_id = sqlc.arg('user_id')
+AND user_follows.status = 'accepted'
+AND users.deletion_scheduled_at IS NULL
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (user_follows.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY user_follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetFollowCounts :one
+SELECT
+	(SELECT COUNT(*) FROM user_follows f
+		INNER JOIN users ON users.id = f.follower_id
+		WHERE f.followed_id = $1 AND f.status = 'accepted' AND users.deletion_scheduled_at IS NULL) AS followers_count,
+	(SELECT COUNT(*) FROM user_follows f
+		INNER JOIN users ON users.id = f.followed_id
+		WHERE f.follower_id = $1 AND f.status = 'accepted' AND users.deletion_scheduled_at IS NULL) AS following_count;
+
+-- name: GetPendingFollowRequests :many
+SELECT users.id, users.name, COALESCE(user_settings.avatar_url, '')::text AS avatar_url, user_follows.created_at AS requested_at
+FROM user_follows
+INNER JOIN users ON users.id = user_follows.follower_id
+LEFT JOIN user_settings ON user_settings.user_id = users.id
+WHERE user_follows.followed_id = sqlc.arg('followed_id')
+AND user_follows.status = 'pending'
+AND users.deletion_scheduled_at IS NULL
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (user_follows.created_at, users.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY user_follows.created_at, users.id
+LIMIT sqlc.arg('page_size');
+
+-- name: AcceptFollowRequest :execrows
+UPDATE user_follows
+SET status = 'accepted'
+WHERE follower_id = $1
+AND followed_id = $2
+AND status = 'pending';
+
+-- name: AcceptAllFollowRequests :exec
+UPDATE user_follows
+SET status = 'accepted'
+WHERE followed_id = $1
+AND status = 'pending';
+
+-- name: RejectFollowRequest :execrows
+DELETE FROM user_follows
+WHERE follower_id = $1
+AND followed_id = $2
+AND status = 'pending';
@@ -0,0 +1,18 @@
+-- name: GetLoginAttempts :one
+SELECT * FROM login_attempts WHERE attempt_key = $1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_attempts (attempt_key, failures, last_failure_at)
+VALUES ($1, 1, $2)
+ON CONFLICT (attempt_key) DO UPDATE
+SET failures = login_attempts.failures + 1,
+last_failure_at = EXCLUDED.last_failure_at
+RETURNING *;
+
+-- name: LockLoginAttempts :exec
+UPDATE login_attempts
+SET locked_until = $2
+WHERE attempt_key = $1;
+
+-- name: DeleteLoginAttempts :exec
+DELETE FROM login_attempts WHERE attempt_key = $1;
@@ -0,0 +1,37 @@
+-- name: CreateMediaFile :one
+INSERT INTO media_files(id, user_id, storage_key, thumbnail_key, content_type, size_bytes, width, height)
+VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8
+		)
+RETURNING *;
+
+-- name: AttachUserMediaFiles :many
+-- Marks the uploads of the user attached, so the cleanup keeps them, and
+-- returns them. Uploads of other users are left out.
+UPDATE media_files
+SET attached_at = COALESCE(attached_at, NOW())
+WHERE user_id = sqlc.arg('user_id')
+AND id = ANY(sqlc.arg('ids')::uuid[])
+RETURNING *;
+
+-- name: GetMediaFilesOfUser :many
+SELECT * FROM media_files
+WHERE user_id = $1;
+
+-- name: GetUnattachedMediaFiles :many
+-- Uploads created before the cutoff that were never attached.
+SELECT * FROM media_files
+WHERE attached_at IS NULL
+AND created_at < sqlc.arg('before')
+ORDER BY created_at
+LIMIT sqlc.arg('max_files');
+
+-- name: DeleteMediaFile :exec
+DELETE FROM media_files WHERE id = $1;
@@ -0,0 +1,34 @@
+-- name: CreateNotification :exec
+INSERT INTO notifications(user_id, actor_id, type, post_id, comment_id)
+VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5
+		);
+
+-- name: GetNotifications :many
+SELECT notifications.*, users.name as actor_name
+FROM notifications
+INNER JOIN users ON users.id = notifications.actor_id
+WHERE notifications.user_id = sqlc.arg('user_id')
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = notifications.user_id AND blocked_id = notifications.actor_id)
+		OR (blocker_id = notifications.actor_id AND blocked_id = notifications.user_id))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (notifications.created_at, notifications.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY notifications.created_at DESC, notifications.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1
+AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+AND read_at IS NULL;
@@ -0,0 +1,33 @@
+-- name: CreateOAuthState :exec
+INSERT INTO oauth_states (state_hash, provider, code_verifier, nonce, link_user_id, cookie_session, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ConsumeOAuthState :one
+DELETE FROM oauth_states
+WHERE state_hash = $1
+AND provider = $2
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: CleanExpiredOAuthStates :exec
+DELETE FROM oauth_states WHERE expires_at <= NOW();
+
+-- name: CreateUserIdentity :one
+INSERT INTO user_identities (user_id, provider, subject, email)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetUserIdentityBySubject :one
+SELECT * FROM user_identities
+WHERE provider = $1
+AND subject = $2;
+
+-- name: GetUserIdentities :many
+SELECT * FROM user_identities
+WHERE user_id = $1
+ORDER BY created_at;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities
+WHERE user_id = $1
+AND provider = $2;
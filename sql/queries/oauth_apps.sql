@@ -0,0 +1,68 @@
+-- name: CreateOAuthClient :one
+INSERT INTO oauth_clients (client_id, client_secret_hash, owner_id, name, redirect_uris)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetOAuthClient :one
+SELECT * FROM oauth_clients WHERE client_id = $1;
+
+-- name: GetOwnedOAuthClients :many
+SELECT * FROM oauth_clients
+WHERE owner_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteOwnedOAuthClient :execrows
+DELETE FROM oauth_clients
+WHERE client_id = $1
+AND owner_id = $2;
+
+-- name: UpsertOAuthGrant :one
+INSERT INTO oauth_grants (user_id, client_id, scopes)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, client_id) DO UPDATE
+SET scopes = EXCLUDED.scopes,
+updated_at = NOW()
+RETURNING *;
+
+-- name: GetOAuthGrantByID :one
+SELECT * FROM oauth_grants WHERE id = $1;
+
+-- name: GetUserOAuthGrants :many
+SELECT oauth_grants.client_id, oauth_grants.scopes, oauth_grants.created_at, oauth_grants.updated_at, oauth_clients.name
+FROM oauth_grants
+INNER JOIN oauth_clients ON oauth_grants.client_id = oauth_clients.client_id
+WHERE oauth_grants.user_id = $1
+ORDER BY oauth_grants.updated_at DESC;
+
+-- name: DeleteUserOAuthGrant :execrows
+DELETE FROM oauth_grants
+WHERE user_id = $1
+AND client_id = $2;
+
+-- name: DeleteOAuthGrant :exec
+DELETE FROM oauth_grants WHERE id = $1;
+
+-- name: CreateOAuthAuthorizationCode :exec
+INSERT INTO oauth_authorization_codes (code_hash, grant_id, redirect_uri, redirect_uri_explicit, scopes, code_challenge, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ConsumeOAuthAuthorizationCode :one
+DELETE FROM oauth_authorization_codes
+WHERE code_hash = $1
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: CreateOAuthRefreshToken :exec
+INSERT INTO oauth_refresh_tokens (token_hash, grant_id, scopes, expires_at)
+VALUES ($1, $2, $3, $4);
+
+-- name: RotateOAuthRefreshToken :one
+UPDATE oauth_refresh_tokens
+SET rotated_at = NOW()
+WHERE token_hash = $1
+AND rotated_at IS NULL
+AND expires_at > NOW()
+RETURNING *;
+
+-- name: GetOAuthRefreshToken :one
+SELECT * FROM oauth_refresh_tokens WHERE token_hash = $1;
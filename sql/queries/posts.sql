@@ -1,32 +1,87 @@
 -- name: CreatePost :one
-INSERT INTO posts(user_id, content, media_urls, visibility)
+INSERT INTO posts(user_id, content, media_urls, visibility, workout_id, program_id, repost_of)
 VALUES (
 		$1,
 		$2,
 		$3,
-		$4
+		$4,
+		$5,
+		$6,
+		$7
 		)
 RETURNING *;
 
 -- name: GetPost :one
 SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content, 
 		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
-		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id) as comments_count
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
 FROM posts
 LEFT JOIN users ON posts.user_id = users.id
-WHERE posts.id = $1;
+WHERE posts.id = $1
+AND posts.deleted_at IS NULL;
 
 -- name: GetFollowedPosts :many
+-- An item shared by several followed users shows up once, as its newest
+-- plain repost.
 SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
 		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
-		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id) as comments_count,
-		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = $1) as user_liked
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = sqlc.arg('user_id')) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
 FROM posts 
 INNER JOIN user_follows ON posts.user_id = user_follows.followed_id
 INNER JOIN users ON posts.user_id = users.id
-WHERE user_follows.follower_id = $1
+WHERE user_follows.follower_id = sqlc.arg('user_id')
+AND user_follows.status = 'accepted'
+AND posts.deleted_at IS NULL
 AND posts.visibility IN ('public', 'followers')
-ORDER BY posts.created_at DESC;
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.arg('user_id') AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = sqlc.arg('user_id')))
+AND NOT EXISTS (SELECT 1 FROM posts newer
+		INNER JOIN user_follows newer_follows ON newer.user_id = newer_follows.followed_id
+		WHERE newer_follows.follower_id = sqlc.arg('user_id') AND newer_follows.status = 'accepted'
+		AND newer.repost_of = (CASE WHEN posts.content = '' AND posts.repost_of IS NOT NULL THEN posts.repost_of ELSE posts.id END)
+		AND newer.content = ''
+		AND newer.deleted_at IS NULL
+		AND newer.visibility IN ('public', 'followers')
+		AND (newer.created_at, newer.id) > (posts.created_at, posts.id)
+		AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.arg('user_id') AND muted_id = newer.user_id)
+		AND NOT EXISTS (SELECT 1 FROM user_blocks
+			WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = newer.user_id)
+			OR (blocker_id = newer.user_id AND blocked_id = sqlc.arg('user_id'))))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (posts.created_at, posts.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetVisiblePosts :many
+-- The posts out of ids the viewer may see and didn't mute, reposted posts
+-- are embedded with them.
+SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
+		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = sqlc.narg('viewer_id')) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
+FROM posts
+INNER JOIN users ON posts.user_id = users.id
+WHERE posts.id = ANY(sqlc.arg('ids')::uuid[])
+AND posts.deleted_at IS NULL
+AND users.deletion_scheduled_at IS NULL
+AND (posts.user_id = sqlc.narg('viewer_id')
+	OR (posts.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = posts.user_id AND user_settings.profile_visibility = 'private'))
+	OR (posts.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = sqlc.narg('viewer_id') AND followed_id = posts.user_id AND status = 'accepted')))
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = sqlc.narg('viewer_id')));
 
 -- name: GetLikeCount :one
 SELECT COUNT(*)
@@ -40,21 +95,33 @@ VALUES (
 		$2
 		);
 
--- name: CommentOnPost :exec
-INSERT INTO posts_comments(user_id, post_id, content)
+-- name: CommentOnPost :one
+INSERT INTO posts_comments(user_id, post_id, content, parent_comment_id, root_comment_id, depth)
 VALUES (
 		$1,
 		$2,
-		$3
-		);
+		$3,
+		$4,
+		$5,
+		$6
+		)
+RETURNING *;
 
 -- name: GetPostComments :many
-SELECT posts_comments.*, users.name as commenter_name
+SELECT posts_comments.*, users.name as commenter_name,
+		(SELECT COUNT(*) FROM comment_likes WHERE comment_likes.comment_id = posts_comments.id) as like_count,
+		EXISTS(SELECT 1 FROM comment_likes WHERE comment_likes.comment_id = posts_comments.id AND comment_likes.user_id = sqlc.narg('viewer_id')) as user_liked
 FROM posts_comments
 LEFT JOIN users ON posts_comments.user_id = users.id
-WHERE posts_comments.post_id = $1
-ORDER BY posts_comments.created_at
-LIMIT 50;
+WHERE posts_comments.post_id = sqlc.arg('post_id')
+AND posts_comments.parent_comment_id IS NULL
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = posts_comments.user_id)
+		OR (blocker_id = posts_comments.user_id AND blocked_id = sqlc.narg('viewer_id')))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (posts_comments.created_at, posts_comments.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY posts_comments.created_at, posts_comments.id
+LIMIT sqlc.arg('page_size');
 
 -- name: CheckUserLikedPost :one
 SELECT EXISTS (
@@ -69,3 +136,71 @@ DELETE FROM posts_likes
 WHERE post_id = $1
 AND user_id = $2;
 
+
+-- name: LockPostForEdit :one
+SELECT * FROM posts
+WHERE id = $1
+AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: CreatePostRevision :exec
+INSERT INTO post_revisions(post_id, editor_id, content, media_urls, visibility)
+VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5
+		);
+
+-- name: UpdatePost :one
+UPDATE posts
+SET content = $2, media_urls = $3, visibility = $4, edited_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeletePost :exec
+UPDATE posts
+SET deleted_at = NOW(), updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetPostRevisions :many
+SELECT * FROM post_revisions
+WHERE post_id = $1
+ORDER BY created_at DESC;
+
+-- name: LockCommentForEdit :one
+SELECT posts_comments.* FROM posts_comments
+INNER JOIN posts ON posts_comments.post_id = posts.id
+WHERE posts_comments.id = $1
+AND posts_comments.deleted_at IS NULL
+AND posts.deleted_at IS NULL
+FOR UPDATE OF posts_comments;
+
+-- name: CreateCommentRevision :exec
+INSERT INTO comment_revisions(comment_id, editor_id, content)
+VALUES (
+		$1,
+		$2,
+		$3
+		);
+
+-- name: UpdateComment :one
+UPDATE posts_comments
+SET content = $2, edited_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeleteComment :exec
+UPDATE posts_comments
+SET deleted_at = NOW()
+WHERE id = $1;
+
+-- name: GetComment :one
+SELECT * FROM posts_comments
+WHERE id = $1;
+
+-- name: GetCommentRevisions :many
+SELECT * FROM comment_revisions
+WHERE comment_id = $1
+ORDER BY created_at DESC;
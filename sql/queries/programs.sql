@@ -38,13 +38,36 @@ FROM programs
 LEFT JOIN users ON programs.user_id = users.id
 WHERE programs.id = $1;
 
+-- name: GetProgramCards :many
+-- Program cards are embedded in posts sharing the programs.
+SELECT programs.id, programs.name, programs.user_id, programs.description, programs.media_urls, programs.visibility, users.name as author_name,
+		(SELECT COUNT(*) FROM program_days WHERE program_days.program_id = programs.id) as days_count,
+		(SELECT COUNT(*) FROM users_programs WHERE users_programs.program_id = programs.id) as subscribers_count,
+		ARRAY(SELECT tags.name FROM program_tags INNER JOIN tags ON program_tags.tag_id = tags.id
+			WHERE program_tags.program_id = programs.id ORDER BY tags.name)::text[] as tags
+FROM programs
+INNER JOIN users ON programs.user_id = users.id
+WHERE programs.id = ANY(sqlc.arg('ids')::uuid[])
+AND users.deletion_scheduled_at IS NULL;
+
 -- name: GetPrograms :many
+-- The programs the viewer may see, newest first.
 SELECT programs.*, users.name as author_name
-FROM programs 
-LEFT JOIN users 
-ON programs.user_id = users.id
-ORDER BY programs.created_at DESC
-LIMIT 50;
+FROM programs
+INNER JOIN users ON programs.user_id = users.id
+WHERE users.deletion_scheduled_at IS NULL
+AND (programs.user_id = sqlc.narg('viewer_id')
+	OR (programs.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = programs.user_id AND user_settings.profile_visibility = 'private'))
+	OR (programs.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = sqlc.narg('viewer_id') AND followed_id = programs.user_id AND status = 'accepted')))
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = programs.user_id)
+		OR (blocker_id = programs.user_id AND blocked_id = sqlc.narg('viewer_id')))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (programs.created_at, programs.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY programs.created_at DESC, programs.id DESC
+LIMIT sqlc.arg('page_size');
 
 -- name: GetProgramDays :many
 SELECT *
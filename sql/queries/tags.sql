@@ -0,0 +1,109 @@
+-- name: CreateTags :exec
+INSERT INTO tags(name)
+SELECT unnest(sqlc.arg('names')::text[])
+ON CONFLICT (name) DO NOTHING;
+
+-- name: AddPostTags :exec
+INSERT INTO post_tags(post_id, tag_id)
+SELECT sqlc.arg('post_id')::uuid, tags.id
+FROM tags
+WHERE tags.name = ANY(sqlc.arg('names')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: DeletePostTags :exec
+DELETE FROM post_tags
+WHERE post_id = $1;
+
+-- name: AddProgramTags :exec
+INSERT INTO program_tags(program_id, tag_id)
+SELECT sqlc.arg('program_id')::uuid, tags.id
+FROM tags
+WHERE tags.name = ANY(sqlc.arg('names')::text[])
+ON CONFLICT DO NOTHING;
+
+-- name: GetProgramTags :many
+SELECT tags.name
+FROM program_tags
+INNER JOIN tags ON program_tags.tag_id = tags.id
+WHERE program_tags.program_id = $1
+ORDER BY tags.name;
+
+-- name: GetTagPosts :many
+SELECT posts.id, users.id as author_id, users.name as author_name, posts.created_at, posts.visibility, posts.media_urls, posts.content,
+		(SELECT COUNT(*) FROM posts_likes where posts.id = posts_likes.post_id) as like_count,
+		(SELECT COUNT(*) FROM posts_comments where posts.id = posts_comments.post_id AND posts_comments.deleted_at IS NULL) as comments_count,
+		EXISTS(SELECT 1 FROM posts_likes WHERE posts_likes.post_id = posts.id AND posts_likes.user_id = sqlc.narg('viewer_id')) as user_liked,
+		posts.updated_at, posts.edited_at, posts.workout_id, posts.program_id, posts.repost_of,
+		(SELECT COUNT(*) FROM posts reposts WHERE reposts.repost_of = posts.id AND reposts.deleted_at IS NULL) as reposts_count
+FROM posts
+INNER JOIN post_tags ON posts.id = post_tags.post_id
+INNER JOIN tags ON post_tags.tag_id = tags.id
+INNER JOIN users ON posts.user_id = users.id
+WHERE tags.name = sqlc.arg('tag')
+AND posts.deleted_at IS NULL
+AND users.deletion_scheduled_at IS NULL
+AND (posts.user_id = sqlc.narg('viewer_id')
+	OR (posts.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = posts.user_id AND user_settings.profile_visibility = 'private'))
+	OR (posts.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = sqlc.narg('viewer_id') AND followed_id = posts.user_id AND status = 'accepted')))
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = posts.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = posts.user_id)
+		OR (blocker_id = posts.user_id AND blocked_id = sqlc.narg('viewer_id')))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (posts.created_at, posts.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY posts.created_at DESC, posts.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetTagPrograms :many
+SELECT programs.*, users.name as author_name
+FROM programs
+INNER JOIN program_tags ON programs.id = program_tags.program_id
+INNER JOIN tags ON program_tags.tag_id = tags.id
+INNER JOIN users ON programs.user_id = users.id
+WHERE tags.name = sqlc.arg('tag')
+AND users.deletion_scheduled_at IS NULL
+AND (programs.user_id = sqlc.narg('viewer_id')
+	OR (programs.visibility = 'public' AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = programs.user_id AND user_settings.profile_visibility = 'private'))
+	OR (programs.visibility IN ('public', 'followers') AND EXISTS (SELECT 1 FROM user_follows
+		WHERE follower_id = sqlc.narg('viewer_id') AND followed_id = programs.user_id AND status = 'accepted')))
+AND NOT EXISTS (SELECT 1 FROM user_mutes WHERE muter_id = sqlc.narg('viewer_id') AND muted_id = programs.user_id)
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = programs.user_id)
+		OR (blocker_id = programs.user_id AND blocked_id = sqlc.narg('viewer_id')))
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (programs.created_at, programs.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY programs.created_at DESC, programs.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetTrendingTags :many
+-- Only public posts and programs of public accounts count, tags used by
+-- many people rank above tags one person keeps repeating.
+SELECT tags.name, COUNT(*) as uses, COUNT(DISTINCT tagged.user_id) as authors
+FROM (
+	SELECT post_tags.tag_id, posts.user_id
+	FROM post_tags
+	INNER JOIN posts ON post_tags.post_id = posts.id
+	WHERE posts.created_at >= sqlc.arg('since')::timestamp
+	AND posts.deleted_at IS NULL
+	AND posts.visibility = 'public'
+	UNION ALL
+	SELECT program_tags.tag_id, programs.user_id
+	FROM program_tags
+	INNER JOIN programs ON program_tags.program_id = programs.id
+	WHERE programs.created_at >= sqlc.arg('since')::timestamp
+	AND programs.visibility = 'public'
+) AS tagged
+INNER JOIN tags ON tagged.tag_id = tags.id
+INNER JOIN users ON tagged.user_id = users.id
+WHERE users.deletion_scheduled_at IS NULL
+AND NOT EXISTS (SELECT 1 FROM user_settings
+		WHERE user_settings.user_id = tagged.user_id AND user_settings.profile_visibility = 'private')
+AND NOT EXISTS (SELECT 1 FROM user_blocks
+		WHERE (blocker_id = sqlc.narg('viewer_id') AND blocked_id = tagged.user_id)
+		OR (blocker_id = tagged.user_id AND blocked_id = sqlc.narg('viewer_id')))
+GROUP BY tags.id
+ORDER BY authors DESC, uses DESC, tags.name
+LIMIT sqlc.arg('max_tags');
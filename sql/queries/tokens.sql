@@ -1,11 +1,15 @@
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
-VALUES ($1, $2, $3)
+INSERT INTO refresh_tokens (user_id, token_hash, expires_at, family_id, user_agent, ip_address)
+VALUES ($1, $2, $3, $4, $5, $6)
 RETURNING *;
 
 -- name: GetRefreshToken :one
 SELECT * FROM refresh_tokens WHERE token_hash=$1;
 
+-- name: RotateRefreshToken :execrows
+UPDATE refresh_tokens SET rotated_at = NOW(), last_used_at = NOW()
+WHERE id=$1 AND rotated_at IS NULL;
+
 -- name: DeleteRefreshToken :exec
 DELETE FROM refresh_tokens WHERE token_hash=$1;
 
@@ -14,3 +18,28 @@ DELETE FROM refresh_tokens WHERE user_id=$1;
 
 -- name: CleanExpiredRefreshTokens :exec
 DELETE FROM refresh_tokens WHERE expires_at < NOW();
+
+-- name: GetUserSessions :many
+SELECT t.family_id,
+		(SELECT MIN(f.created_at) FROM refresh_tokens f WHERE f.family_id = t.family_id)::timestamp AS session_created_at,
+		t.last_used_at, t.user_agent, t.ip_address, t.expires_at
+FROM refresh_tokens t
+WHERE t.user_id = $1
+AND t.rotated_at IS NULL
+AND t.expires_at > NOW()
+ORDER BY t.last_used_at DESC;
+
+-- name: DeleteUserSession :execrows
+DELETE FROM refresh_tokens
+WHERE family_id = $1
+AND user_id = $2;
+
+-- name: CreateAuthEvent :exec
+INSERT INTO auth_events (user_id, event_type, details, ip_address)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetAuthEvents :many
+SELECT * FROM auth_events
+WHERE (sqlc.narg('user_id')::uuid IS NULL OR user_id = sqlc.narg('user_id'))
+ORDER BY created_at DESC
+LIMIT 100;
@@ -0,0 +1,57 @@
+-- name: UpsertUserTOTP :execrows
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret,
+last_used_step = 0,
+created_at = NOW()
+WHERE user_totp.confirmed_at IS NULL;
+
+-- name: GetUserTOTP :one
+SELECT * FROM user_totp WHERE user_id = $1;
+
+-- name: ConfirmUserTOTP :exec
+UPDATE user_totp
+SET confirmed_at = NOW()
+WHERE user_id = $1;
+
+-- name: UseTOTPStep :execrows
+UPDATE user_totp
+SET last_used_step = $2
+WHERE user_id = $1
+AND last_used_step < $2;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp WHERE user_id = $1;
+
+-- name: CreateRecoveryCode :exec
+INSERT INTO totp_recovery_codes (user_id, code_hash)
+VALUES ($1, $2);
+
+-- name: UseRecoveryCode :execrows
+UPDATE totp_recovery_codes
+SET used_at = NOW()
+WHERE user_id = $1
+AND code_hash = $2
+AND used_at IS NULL;
+
+-- name: DeleteUserRecoveryCodes :exec
+DELETE FROM totp_recovery_codes WHERE user_id = $1;
+
+-- name: CreateMFAChallenge :exec
+INSERT INTO mfa_challenges (user_id, token_hash, expires_at)
+VALUES ($1, $2, $3);
+
+-- name: GetMFAChallenge :one
+SELECT * FROM mfa_challenges
+WHERE token_hash = $1
+AND expires_at > NOW();
+
+-- name: IncrementMFAChallengeAttempts :one
+UPDATE mfa_challenges
+SET attempts = attempts + 1
+WHERE id = $1
+RETURNING attempts;
+
+-- name: DeleteMFAChallenge :exec
+DELETE FROM mfa_challenges WHERE id = $1;
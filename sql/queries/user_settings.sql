@@ -0,0 +1,14 @@
+-- name: GetUserSettings :one
+SELECT * FROM user_settings
+WHERE user_id = $1;
+
+-- name: UpsertUserSettings :one
+INSERT INTO user_settings (user_id, avatar_url, weight_unit, timezone, profile_visibility, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
+ON CONFLICT (user_id) DO UPDATE
+SET avatar_url = EXCLUDED.avatar_url,
+weight_unit = EXCLUDED.weight_unit,
+timezone = EXCLUDED.timezone,
+profile_visibility = EXCLUDED.profile_visibility,
+updated_at = NOW()
+RETURNING *;
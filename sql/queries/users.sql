@@ -12,12 +12,12 @@ VALUES (
 RETURNING *;
 
 -- name: GetUser :one
-SELECT id, created_at, updated_at, name, email, bio, premium
+SELECT id, created_at, updated_at, name, email, bio, premium, role, email_verified_at, deletion_scheduled_at
 FROM users
 WHERE id = $1;
 
 -- name: GetUserLogin :one
-SELECT id, created_at, updated_at, name, email, bio, premium, hashed_password
+SELECT id, created_at, updated_at, name, email, bio, premium, hashed_password, role
 FROM users
 WHERE email = $1;
 
@@ -27,15 +27,41 @@ SET bio = $2,
 updated_at = Now()
 WHERE id = $1;
 
--- name: FollowUser :exec
-INSERT INTO user_follows(follower_id, followed_id)
-VALUES (
-		$1,
-		$2
-		);
+-- name: UpdateUserProfile :exec
+UPDATE users
+SET name = $2,
+bio = $3,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: ChangeUserEmail :execrows
+UPDATE users
+SET email = $2,
+email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: MarkEmailVerified :execrows
+UPDATE users
+SET email_verified_at = NOW(),
+updated_at = NOW()
+WHERE id = $1
+AND email = $2;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET hashed_password = $2,
+updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserRole :execrows
+UPDATE users
+SET role = $2,
+updated_at = NOW()
+WHERE id = $1;
 
--- name: GetFollowedUsers :many
-SELECT users.id, users.name, users.created_at, users.updated_at, users.bio, users.premium
+-- name: GetUsersByNames :many
+SELECT id, lower(name)::text AS name
 FROM users
-INNER JOIN user_follows ON users.id = user_follows.followed_id
-WHERE user_follows.follower_id = $1;
+WHERE lower(name) = ANY(sqlc.arg('names')::text[])
+AND deletion_scheduled_at IS NULL;
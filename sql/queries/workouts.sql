@@ -25,8 +25,11 @@ ORDER BY created_at DESC;
 
 -- name: GetUsersWorkouts :many
 SELECT * FROM workouts
-WHERE user_id = $1
-ORDER BY created_at DESC;
+WHERE user_id = sqlc.arg('user_id')
+AND (sqlc.narg('cursor_created_at')::timestamp IS NULL
+	OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
 
 -- name: GetWorkoutByID :one
 SELECT * FROM workouts
@@ -36,3 +39,21 @@ WHERE id = $1;
 SELECT * FROM users_lifts
 WHERE workout_id = $1
 ORDER BY lift_order ASC;
+
+-- name: GetWorkoutSummaries :many
+-- One row per lift of the workouts, workouts without lifts get one row
+-- without a lift. A lift is a PR when its weight beats every earlier lift of
+-- the exercise by the user, the first lift of an exercise isn't one.
+SELECT workouts.id as workout_id, workouts.user_id, workouts.created_at, program_days.name as day_name, programs.id as program_id, programs.name as program_name, programs.user_id as program_author_id, programs.visibility as program_visibility,
+		exercises.name as exercise_name, users_lifts.weight, users_lifts.sets, users_lifts.reps,
+		COALESCE(users_lifts.weight > (SELECT MAX(earlier.weight) FROM users_lifts earlier
+			INNER JOIN workouts earlier_workouts ON earlier.workout_id = earlier_workouts.id
+			WHERE earlier.user_id = users_lifts.user_id AND earlier.exercise_id = users_lifts.exercise_id
+			AND earlier_workouts.created_at < workouts.created_at), false)::boolean as pr
+FROM workouts
+INNER JOIN program_days ON workouts.program_day_id = program_days.id
+INNER JOIN programs ON program_days.program_id = programs.id
+LEFT JOIN users_lifts ON users_lifts.workout_id = workouts.id
+LEFT JOIN exercises ON users_lifts.exercise_id = exercises.id
+WHERE workouts.id = ANY(sqlc.arg('ids')::uuid[])
+ORDER BY workouts.id, users_lifts.lift_order;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID NOT NULL DEFAULT gen_random_uuid(),
+ADD COLUMN rotated_at TIMESTAMP;
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens(family_id);
+
+CREATE TABLE auth_events(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+event_type TEXT NOT NULL,
+details TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_auth_events_user_id ON auth_events(user_id);
+
+-- +goose Down
+DROP TABLE auth_events;
+DROP INDEX idx_refresh_tokens_family_id;
+ALTER TABLE refresh_tokens
+DROP COLUMN rotated_at,
+DROP COLUMN family_id;
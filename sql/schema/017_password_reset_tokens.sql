@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE password_reset_tokens(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+token_hash TEXT NOT NULL UNIQUE,
+expires_at TIMESTAMP NOT NULL,
+used_at TIMESTAMP);
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+
+-- +goose Down
+DROP TABLE password_reset_tokens;
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN email_verified_at TIMESTAMP;
+-- accounts from before verification existed count as verified
+UPDATE users SET email_verified_at = created_at;
+
+CREATE TABLE email_verification_tokens(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+email TEXT NOT NULL,
+token_hash TEXT NOT NULL UNIQUE,
+expires_at TIMESTAMP NOT NULL);
+CREATE INDEX idx_email_verification_tokens_user_id ON email_verification_tokens(user_id);
+
+-- +goose Down
+DROP TABLE email_verification_tokens;
+ALTER TABLE users
+DROP COLUMN email_verified_at;
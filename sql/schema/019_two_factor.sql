@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE user_totp(
+user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+secret TEXT NOT NULL,
+confirmed_at TIMESTAMP,
+last_used_step BIGINT NOT NULL DEFAULT 0,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+CREATE TABLE totp_recovery_codes(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+code_hash TEXT NOT NULL,
+used_at TIMESTAMP,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_totp_recovery_codes_user_id ON totp_recovery_codes(user_id);
+
+CREATE TABLE mfa_challenges(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+token_hash TEXT NOT NULL UNIQUE,
+attempts INTEGER NOT NULL DEFAULT 0,
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+-- +goose Down
+DROP TABLE mfa_challenges;
+DROP TABLE totp_recovery_codes;
+DROP TABLE user_totp;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE login_attempts(
+attempt_key TEXT PRIMARY KEY,
+failures INTEGER NOT NULL DEFAULT 0,
+last_failure_at TIMESTAMP NOT NULL,
+locked_until TIMESTAMP);
+
+ALTER TABLE auth_events
+ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+CREATE INDEX idx_auth_events_created_at ON auth_events(created_at);
+
+-- +goose Down
+DROP INDEX idx_auth_events_created_at;
+ALTER TABLE auth_events
+DROP COLUMN ip_address;
+DROP TABLE login_attempts;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE api_keys(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+name TEXT NOT NULL,
+key_prefix TEXT NOT NULL,
+key_hash TEXT NOT NULL UNIQUE,
+scopes TEXT[] NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+last_used_at TIMESTAMP,
+expires_at TIMESTAMP);
+CREATE INDEX idx_api_keys_user_id ON api_keys(user_id);
+
+-- +goose Down
+DROP TABLE api_keys;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE user_identities(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+provider TEXT NOT NULL,
+subject TEXT NOT NULL,
+email TEXT NOT NULL DEFAULT '',
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+UNIQUE(provider, subject),
+UNIQUE(user_id, provider));
+
+CREATE TABLE oauth_states(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+state_hash TEXT NOT NULL UNIQUE,
+provider TEXT NOT NULL,
+code_verifier TEXT NOT NULL,
+nonce TEXT NOT NULL,
+link_user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+cookie_session BOOLEAN NOT NULL DEFAULT FALSE,
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+-- +goose Down
+DROP TABLE oauth_states;
+DROP TABLE user_identities;
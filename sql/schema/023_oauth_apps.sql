@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE oauth_clients(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+client_id TEXT NOT NULL UNIQUE,
+-- empty for public clients, which have to use PKCE instead
+client_secret_hash TEXT NOT NULL DEFAULT '',
+owner_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+name TEXT NOT NULL,
+redirect_uris TEXT[] NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_oauth_clients_owner_id ON oauth_clients(owner_id);
+
+CREATE TABLE oauth_grants(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+client_id TEXT NOT NULL REFERENCES oauth_clients(client_id) ON DELETE CASCADE,
+scopes TEXT[] NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+UNIQUE(user_id, client_id));
+
+CREATE TABLE oauth_authorization_codes(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+code_hash TEXT NOT NULL UNIQUE,
+grant_id UUID NOT NULL REFERENCES oauth_grants(id) ON DELETE CASCADE,
+redirect_uri TEXT NOT NULL,
+scopes TEXT[] NOT NULL,
+code_challenge TEXT NOT NULL DEFAULT '',
+expires_at TIMESTAMP NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+CREATE TABLE oauth_refresh_tokens(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+token_hash TEXT NOT NULL UNIQUE,
+grant_id UUID NOT NULL REFERENCES oauth_grants(id) ON DELETE CASCADE,
+scopes TEXT[] NOT NULL,
+expires_at TIMESTAMP NOT NULL,
+rotated_at TIMESTAMP,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+-- +goose Down
+DROP TABLE oauth_refresh_tokens;
+DROP TABLE oauth_authorization_codes;
+DROP TABLE oauth_grants;
+DROP TABLE oauth_clients;
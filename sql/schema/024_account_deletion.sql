@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMP;
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+
+-- +goose Down
+DROP INDEX idx_users_deletion_scheduled_at;
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE user_settings(
+user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+avatar_url TEXT NOT NULL DEFAULT '',
+weight_unit TEXT NOT NULL DEFAULT 'kg' CHECK (weight_unit IN ('kg', 'lb')),
+timezone TEXT NOT NULL DEFAULT 'UTC',
+profile_visibility TEXT NOT NULL DEFAULT 'public' CHECK (profile_visibility IN ('public', 'followers', 'private')),
+updated_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+ALTER TABLE email_verification_tokens
+ADD COLUMN email_change BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE email_verification_tokens
+DROP COLUMN email_change;
+DROP TABLE user_settings;
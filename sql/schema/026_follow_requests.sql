@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE user_follows
+ADD COLUMN status TEXT NOT NULL DEFAULT 'accepted' CHECK (status IN ('pending', 'accepted'));
+CREATE INDEX idx_user_follows_followed_id_status ON user_follows(followed_id, status);
+
+-- +goose Down
+DROP INDEX idx_user_follows_followed_id_status;
+ALTER TABLE user_follows
+DROP COLUMN status;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE user_blocks(
+blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+PRIMARY KEY (blocker_id, blocked_id));
+CREATE INDEX idx_user_blocks_blocked_id ON user_blocks(blocked_id);
+
+CREATE TABLE user_mutes(
+muter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+muted_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+PRIMARY KEY (muter_id, muted_id));
+
+-- +goose Down
+DROP TABLE user_mutes;
+DROP TABLE user_blocks;
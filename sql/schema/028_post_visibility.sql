@@ -0,0 +1,11 @@
+-- +goose Up
+-- posts stored without a known visibility, including an empty string, were
+-- never shown to other users, so they stay hidden
+UPDATE posts SET visibility = 'private'
+WHERE visibility IS NULL OR visibility NOT IN ('public', 'followers', 'private');
+ALTER TABLE posts
+ADD CONSTRAINT posts_visibility_check CHECK (visibility IN ('public', 'followers', 'private'));
+
+-- +goose Down
+ALTER TABLE posts
+DROP CONSTRAINT posts_visibility_check;
@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN edited_at TIMESTAMP,
+ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE posts_comments
+ADD COLUMN edited_at TIMESTAMP,
+ADD COLUMN deleted_at TIMESTAMP;
+
+-- revisions keep the content as it was before each edit
+CREATE TABLE post_revisions(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+content TEXT NOT NULL,
+media_urls TEXT[],
+visibility VARCHAR NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_post_revisions_post_id ON post_revisions(post_id);
+
+CREATE TABLE comment_revisions(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+comment_id UUID NOT NULL REFERENCES posts_comments(id) ON DELETE CASCADE,
+editor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+content TEXT NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_comment_revisions_comment_id ON comment_revisions(comment_id);
+
+-- +goose Down
+DROP TABLE comment_revisions;
+DROP TABLE post_revisions;
+ALTER TABLE posts_comments
+DROP COLUMN deleted_at,
+DROP COLUMN edited_at;
+ALTER TABLE posts
+DROP COLUMN deleted_at,
+DROP COLUMN edited_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- lists are paged by (created_at, id), which needs created_at on every row
+UPDATE workouts SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE workouts ALTER COLUMN created_at SET NOT NULL;
+UPDATE user_follows SET created_at = NOW() WHERE created_at IS NULL;
+ALTER TABLE user_follows ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX idx_posts_created_at_id ON posts(created_at, id);
+CREATE INDEX idx_posts_comments_post_id_created_at ON posts_comments(post_id, created_at, id);
+CREATE INDEX idx_programs_created_at_id ON programs(created_at, id);
+CREATE INDEX idx_exercises_created_at_id ON exercises(created_at, id);
+CREATE INDEX idx_workouts_user_id_created_at ON workouts(user_id, created_at, id);
+
+-- +goose Down
+DROP INDEX idx_workouts_user_id_created_at;
+DROP INDEX idx_exercises_created_at_id;
+DROP INDEX idx_programs_created_at_id;
+DROP INDEX idx_posts_comments_post_id_created_at;
+DROP INDEX idx_posts_created_at_id;
+ALTER TABLE user_follows ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE workouts ALTER COLUMN created_at DROP NOT NULL;
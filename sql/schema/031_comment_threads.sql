@@ -0,0 +1,34 @@
+-- +goose Up
+-- root_comment_id is the top level comment of the thread, replies of a page
+-- of threads are loaded with it in one query
+ALTER TABLE posts_comments
+ADD COLUMN parent_comment_id UUID REFERENCES posts_comments(id) ON DELETE CASCADE,
+ADD COLUMN root_comment_id UUID REFERENCES posts_comments(id) ON DELETE CASCADE,
+ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX idx_posts_comments_root_comment_id ON posts_comments(root_comment_id);
+
+CREATE TABLE comment_likes(
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+comment_id UUID NOT NULL REFERENCES posts_comments(id) ON DELETE CASCADE,
+created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+PRIMARY KEY (user_id, comment_id));
+CREATE INDEX idx_comment_likes_comment_id ON comment_likes(comment_id);
+
+CREATE TABLE notifications(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+type VARCHAR NOT NULL,
+post_id UUID REFERENCES posts(id) ON DELETE CASCADE,
+comment_id UUID REFERENCES posts_comments(id) ON DELETE CASCADE,
+read_at TIMESTAMP,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_notifications_user_id_created_at ON notifications(user_id, created_at, id);
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE comment_likes;
+ALTER TABLE posts_comments
+DROP COLUMN depth,
+DROP COLUMN root_comment_id,
+DROP COLUMN parent_comment_id;
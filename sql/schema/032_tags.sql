@@ -0,0 +1,34 @@
+-- +goose Up
+-- programs created without a visibility were stored with an empty string and
+-- were public, anything else unknown is kept hidden
+UPDATE programs SET visibility = 'public'
+WHERE visibility IS NULL OR visibility = '';
+UPDATE programs SET visibility = 'private'
+WHERE visibility NOT IN ('public', 'followers', 'private');
+ALTER TABLE programs
+ADD CONSTRAINT programs_visibility_check CHECK (visibility IN ('public', 'followers', 'private'));
+
+CREATE TABLE tags(
+id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+name TEXT NOT NULL UNIQUE,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+
+-- post tags are parsed from the content, program tags are set by the author
+CREATE TABLE post_tags(
+post_id UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+PRIMARY KEY (post_id, tag_id));
+CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
+
+CREATE TABLE program_tags(
+program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+PRIMARY KEY (program_id, tag_id));
+CREATE INDEX idx_program_tags_tag_id ON program_tags(tag_id);
+
+-- +goose Down
+DROP TABLE program_tags;
+DROP TABLE post_tags;
+DROP TABLE tags;
+ALTER TABLE programs
+DROP CONSTRAINT programs_visibility_check;
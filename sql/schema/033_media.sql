@@ -0,0 +1,17 @@
+-- +goose Up
+-- uploaded images, posts, exercises and programs keep the URLs of the ones
+-- attached to them in media_urls
+CREATE TABLE media_files(
+id UUID PRIMARY KEY,
+user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+storage_key TEXT NOT NULL,
+thumbnail_key TEXT NOT NULL,
+content_type TEXT NOT NULL,
+size_bytes BIGINT NOT NULL,
+width INTEGER NOT NULL,
+height INTEGER NOT NULL,
+created_at TIMESTAMP NOT NULL DEFAULT NOW());
+CREATE INDEX idx_media_files_user_id ON media_files(user_id);
+
+-- +goose Down
+DROP TABLE media_files;
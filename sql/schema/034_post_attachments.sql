@@ -0,0 +1,16 @@
+-- +goose Up
+-- a post shares at most one workout or program, the post stays when they
+-- are deleted
+ALTER TABLE posts
+ADD COLUMN workout_id UUID REFERENCES workouts(id) ON DELETE SET NULL,
+ADD COLUMN program_id UUID REFERENCES programs(id) ON DELETE SET NULL,
+ADD CONSTRAINT posts_one_attachment CHECK (workout_id IS NULL OR program_id IS NULL);
+CREATE INDEX idx_posts_workout_id ON posts(workout_id);
+CREATE INDEX idx_posts_program_id ON posts(program_id);
+CREATE INDEX idx_users_lifts_user_exercise ON users_lifts(user_id, exercise_id);
+
+-- +goose Down
+DROP INDEX idx_users_lifts_user_exercise;
+ALTER TABLE posts
+DROP COLUMN program_id,
+DROP COLUMN workout_id;
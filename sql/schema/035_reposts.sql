@@ -0,0 +1,14 @@
+-- +goose Up
+-- reposts are posts with repost_of set, plain reposts have no content of
+-- their own and quote posts do. A user reposts a post once, quoting it as
+-- often as they like. Reposts are other users' posts, so they outlive the
+-- original when its author is purged.
+ALTER TABLE posts
+ADD COLUMN repost_of UUID REFERENCES posts(id) ON DELETE SET NULL;
+CREATE INDEX idx_posts_repost_of ON posts(repost_of);
+CREATE UNIQUE INDEX posts_one_repost ON posts(user_id, repost_of) WHERE content = '' AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX posts_one_repost;
+ALTER TABLE posts
+DROP COLUMN repost_of;
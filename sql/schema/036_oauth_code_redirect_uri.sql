@@ -0,0 +1,9 @@
+-- +goose Up
+-- a redirect_uri given in the authorization request has to be repeated when
+-- the code is exchanged (RFC 6749 4.1.3), one left to the default doesn't
+ALTER TABLE oauth_authorization_codes
+ADD COLUMN redirect_uri_explicit BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE oauth_authorization_codes
+DROP COLUMN redirect_uri_explicit;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- comments of purged users stay in their threads as deleted, without an
+-- author
+ALTER TABLE posts_comments
+ALTER COLUMN user_id DROP NOT NULL;
+
+-- +goose Down
+DELETE FROM posts_comments WHERE user_id IS NULL;
+ALTER TABLE posts_comments
+ALTER COLUMN user_id SET NOT NULL;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- uploads are marked attached when a post, exercise, program or avatar takes
+-- them, the cleanup only looks at uploads that never were. Uploads already in
+-- use are found once by their URL, which ends with the storage key.
+ALTER TABLE media_files
+ADD COLUMN attached_at TIMESTAMP;
+UPDATE media_files SET attached_at = NOW()
+WHERE EXISTS (
+	SELECT 1 FROM posts, unnest(posts.media_urls) url
+	WHERE right(url, length(media_files.storage_key)) = media_files.storage_key
+)
+OR EXISTS (
+	SELECT 1 FROM post_revisions, unnest(post_revisions.media_urls) url
+	WHERE right(url, length(media_files.storage_key)) = media_files.storage_key
+)
+OR EXISTS (
+	SELECT 1 FROM exercises, unnest(exercises.media_urls) url
+	WHERE right(url, length(media_files.storage_key)) = media_files.storage_key
+)
+OR EXISTS (
+	SELECT 1 FROM programs, unnest(programs.media_urls) url
+	WHERE right(url, length(media_files.storage_key)) = media_files.storage_key
+)
+OR EXISTS (
+	SELECT 1 FROM user_settings
+	WHERE right(user_settings.avatar_url, length(media_files.storage_key)) = media_files.storage_key
+);
+CREATE INDEX idx_media_files_unattached ON media_files(created_at) WHERE attached_at IS NULL;
+
+-- +goose Down
+DROP INDEX idx_media_files_unattached;
+ALTER TABLE media_files
+DROP COLUMN attached_at;